@@ -1,16 +1,24 @@
 package boslib
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
+	"time"
 
+	"github.com/stellar/go/amount"
 	b "github.com/stellar/go/build"
 	"github.com/stellar/go/clients/horizon"
 	"github.com/stellar/go/xdr"
 )
 
+// CheckAddressExists checks whether the account exists in network. Only
+// 404 means the account does not exist; the other responses, like 429 by
+// rate limit of horizon, are error.
 func CheckAddressExists(horizonUrl, address string) (exists bool, err error) {
 	exists = false
 
@@ -22,8 +30,17 @@ func CheckAddressExists(horizonUrl, address string) (exists bool, err error) {
 		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
 		return
 	}
+	response.Body.Close()
 
-	return response.StatusCode == 200, nil
+	switch response.StatusCode {
+	case 200:
+		return true, nil
+	case 404:
+		return false, nil
+	}
+
+	err = fmt.Errorf("failed to check account, '%s' in horizon, '%s': %v", address, u.String(), response.StatusCode)
+	return
 }
 
 func LoadSequenceForAccount(horizonUrl, senderAddress string) (xdr.SequenceNumber, error) {
@@ -31,7 +48,9 @@ func LoadSequenceForAccount(horizonUrl, senderAddress string) (xdr.SequenceNumbe
 	return nc.SequenceForAccount(senderAddress)
 }
 
-func CreateAccount(horizonUrl, senderSeed, receiverAddress, amount, networkPassphrase string, seq xdr.SequenceNumber, fee uint64) (
+// CreateAccount creates new account. The extra mutators like memo are added to
+// the transaction.
+func CreateAccount(horizonUrl, senderSeed, receiverAddress, amount, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, muts ...b.TransactionMutator) (
 	resp horizon.TransactionSuccess,
 	err error,
 ) {
@@ -44,15 +63,20 @@ func CreateAccount(horizonUrl, senderSeed, receiverAddress, amount, networkPassp
 		sp = FixedSequence{seq}
 	}
 
-	tx, err := b.Transaction(
-		b.BaseFee{Amount: fee},
-		b.SourceAccount{senderSeed},
-		sp,
-		b.CreateAccount(
-			b.Destination{receiverAddress},
-			b.NativeAmount{amount},
-		),
+	muts = append(
+		[]b.TransactionMutator{
+			b.BaseFee{Amount: fee},
+			b.SourceAccount{senderSeed},
+			sp,
+			b.CreateAccount(
+				b.Destination{receiverAddress},
+				b.NativeAmount{amount},
+			),
+		},
+		muts...,
 	)
+
+	tx, err := b.Transaction(muts...)
 	if err != nil {
 		return
 	}
@@ -79,9 +103,58 @@ func CreateAccount(horizonUrl, senderSeed, receiverAddress, amount, networkPassp
 }
 
 type Balance struct {
-	ID      string
-	Address string
-	Amount  string
+	Line     int    `json:"line,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Address  string `json:"address"`
+	Amount   string `json:"amount"`
+	MemoType string `json:"memo_type,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+// BatchBalances groups the rows by memo, because memo is for the whole
+// transaction, and then splits them by size.
+func BatchBalances(rows []Balance, size int) (batches [][]Balance) {
+	var memos []Memo
+	byMemo := map[Memo][]Balance{}
+	for _, r := range rows {
+		m := Memo{Type: r.MemoType, Value: r.Memo}
+		if _, found := byMemo[m]; !found {
+			memos = append(memos, m)
+		}
+		byMemo[m] = append(byMemo[m], r)
+	}
+
+	for _, m := range memos {
+		rs := byMemo[m]
+		for i := 0; i < len(rs); i += size {
+			end := i + size
+			if end > len(rs) {
+				end = len(rs)
+			}
+			batches = append(batches, rs[i:end])
+		}
+	}
+
+	return
+}
+
+// CreateAccountOperations makes the 'create_account' operations. If source
+// is not empty, it becomes the source account of operations; it is used with
+// the channel account.
+func CreateAccountOperations(source string, receiverAddress ...Balance) (ops []b.TransactionMutator) {
+	for _, i := range receiverAddress {
+		muts := []interface{}{
+			b.Destination{i.Address},
+			b.NativeAmount{i.Amount},
+		}
+		if len(source) > 0 {
+			muts = append(muts, b.SourceAccount{source})
+		}
+
+		ops = append(ops, b.CreateAccount(muts...))
+	}
+
+	return
 }
 
 func CreateAccounts(horizonUrl, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, receiverAddress ...Balance) (
@@ -102,16 +175,7 @@ func CreateAccounts(horizonUrl, senderSeed, networkPassphrase string, seq xdr.Se
 		b.SourceAccount{senderSeed},
 		sp,
 	}
-
-	for _, i := range receiverAddress {
-		muts = append(
-			muts,
-			b.CreateAccount(
-				b.Destination{i.Address},
-				b.NativeAmount{i.Amount},
-			),
-		)
-	}
+	muts = append(muts, CreateAccountOperations("", receiverAddress...)...)
 
 	tx, err := b.Transaction(muts...)
 	if err != nil {
@@ -138,3 +202,227 @@ func CreateAccounts(horizonUrl, senderSeed, networkPassphrase string, seq xdr.Se
 
 	return
 }
+
+type AccountBalance struct {
+	Balance     string `json:"balance"`
+	Limit       string `json:"limit"`
+	AssetType   string `json:"asset_type"`
+	AssetCode   string `json:"asset_code"`
+	AssetIssuer string `json:"asset_issuer"`
+}
+
+func (a AccountBalance) Asset() b.Asset {
+	return AssetFromHorizon(a.AssetType, a.AssetCode, a.AssetIssuer)
+}
+
+type AccountSigner struct {
+	PublicKey string `json:"public_key"`
+	Key       string `json:"key"`
+	Weight    int32  `json:"weight"`
+	Type      string `json:"type"`
+}
+
+type AccountThresholds struct {
+	LowThreshold  uint8 `json:"low_threshold"`
+	MedThreshold  uint8 `json:"med_threshold"`
+	HighThreshold uint8 `json:"high_threshold"`
+}
+
+type AccountFlags struct {
+	AuthRequired  bool `json:"auth_required"`
+	AuthRevocable bool `json:"auth_revocable"`
+	AuthImmutable bool `json:"auth_immutable"`
+}
+
+type Account struct {
+	ID                   string            `json:"id"`
+	Sequence             string            `json:"sequence"`
+	SubentryCount        int32             `json:"subentry_count"`
+	InflationDestination string            `json:"inflation_destination"`
+	HomeDomain           string            `json:"home_domain"`
+	Thresholds           AccountThresholds `json:"thresholds"`
+	Flags                AccountFlags      `json:"flags"`
+	Balances             []AccountBalance  `json:"balances"`
+	Signers              []AccountSigner   `json:"signers"`
+	Data                 map[string]string `json:"data"`
+}
+
+// NativeBalance returns the lumen balance of account.
+func (a Account) NativeBalance() string {
+	for _, i := range a.Balances {
+		if i.AssetType == "native" {
+			return i.Balance
+		}
+	}
+
+	return "0"
+}
+
+// MinimumBalance is the lumens, which the account must keep by the base
+// reserve; it is (2 + subentries) x base reserve.
+func (a Account) MinimumBalance(baseReserve xdr.Int64) xdr.Int64 {
+	return MinimumBalance(baseReserve, a.SubentryCount)
+}
+
+// Spendable is the lumens, which can be sent or paid for the fees; the
+// minimum balance is excluded from the native balance. If the native balance
+// is less than the minimum balance, it is 0.
+func (a Account) Spendable(baseReserve xdr.Int64) xdr.Int64 {
+	balance, err := amount.Parse(a.NativeBalance())
+	if err != nil {
+		return 0
+	}
+
+	if spendable := balance - a.MinimumBalance(baseReserve); spendable > 0 {
+		return spendable
+	}
+
+	return 0
+}
+
+// AccountNotFoundError is returned by LoadAccount if the account does not
+// exist in network.
+type AccountNotFoundError struct {
+	Address string
+}
+
+func (e *AccountNotFoundError) Error() string {
+	return fmt.Sprintf("account, '%s' does not exist", e.Address)
+}
+
+// IsAccountNotFound checks whether the error is AccountNotFoundError.
+func IsAccountNotFound(err error) bool {
+	_, ok := err.(*AccountNotFoundError)
+	return ok
+}
+
+// LoadAccount loads the account information from horizon.
+func LoadAccount(horizonUrl, address string) (account Account, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "accounts", address)
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode == 404 {
+		err = &AccountNotFoundError{Address: address}
+		return
+	} else if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get response from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	if err = json.Unmarshal(body, &account); err != nil {
+		err = fmt.Errorf("invalid account received: %v", err)
+		return
+	}
+
+	return
+}
+
+// AccountResult is the account loaded by LoadAccounts; if it failed to load
+// the account, Error is set.
+type AccountResult struct {
+	Address string
+	Account Account
+	Error   error
+}
+
+// LoadAccounts loads the accounts at once by the given number of workers; the
+// results are in the same order with addresses. If rate is greater than 0,
+// the requests are limited to rate per second.
+func LoadAccounts(horizonUrl string, addresses []string, concurrency int, rate float64) []AccountResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var tick <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	results := make([]AccountResult, len(addresses))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				account, err := LoadAccount(horizonUrl, addresses[n])
+				results[n] = AccountResult{Address: addresses[n], Account: account, Error: err}
+			}
+		}()
+	}
+
+	for n := range addresses {
+		if tick != nil {
+			<-tick
+		}
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// CheckAddressesExist checks whether the accounts exist or not at once by the
+// given number of workers.
+func CheckAddressesExist(horizonUrl string, addresses []string, concurrency int) (exists map[string]bool, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type result struct {
+		address string
+		exists  bool
+		err     error
+	}
+
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range jobs {
+				e, err := CheckAddressExists(horizonUrl, a)
+				results <- result{address: a, exists: e, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, a := range addresses {
+			jobs <- a
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	exists = map[string]bool{}
+	for r := range results {
+		if r.err != nil {
+			err = r.err
+			continue
+		}
+		exists[r.address] = r.exists
+	}
+
+	return
+}
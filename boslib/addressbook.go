@@ -0,0 +1,83 @@
+package boslib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/keypair"
+)
+
+// AddressBook is the labels of the public addresses. It is read from the csv
+// file with 'address' and 'label' columns, see ReadCSV.
+type AddressBook struct {
+	labels    map[string]string // address -> label
+	addresses map[string]string // label -> address
+}
+
+// NewAddressBook makes the empty address book.
+func NewAddressBook() AddressBook {
+	return AddressBook{labels: map[string]string{}, addresses: map[string]string{}}
+}
+
+// ReadAddressBook reads the address book from the csv file; the label must
+// be unique.
+func ReadAddressBook(name string) (book AddressBook, err error) {
+	records, err := ReadCSV(name, 1, "address", "label")
+	if err != nil {
+		return
+	}
+
+	book = NewAddressBook()
+	for _, r := range records {
+		if err = book.Add(r.Address, r.Label); err != nil {
+			err = &CSVError{Line: r.Line, err: err}
+			return
+		}
+	}
+
+	return
+}
+
+// Add adds the label of address; the secret seed is added by its public
+// address.
+func (b AddressBook) Add(address, label string) error {
+	kp, err := keypair.Parse(strings.TrimSpace(address))
+	if err != nil {
+		return fmt.Errorf("invalid address, '%s': %v", address, err)
+	}
+
+	label = strings.TrimSpace(label)
+	if len(label) < 1 {
+		return nil
+	}
+	if a, found := b.addresses[label]; found && a != kp.Address() {
+		return fmt.Errorf("duplicated label, '%s' of '%s' and '%s'", label, a, kp.Address())
+	}
+
+	b.labels[kp.Address()] = label
+	b.addresses[label] = kp.Address()
+
+	return nil
+}
+
+// Resolve returns the public address of the label; the public address and
+// secret seed are returned as its public address.
+func (b AddressBook) Resolve(s string) (address string, err error) {
+	s = strings.TrimSpace(s)
+	if a, found := b.addresses[s]; found {
+		return a, nil
+	}
+
+	kp, err := keypair.Parse(s)
+	if err != nil {
+		err = fmt.Errorf("'%s' is not address and not found in address book", s)
+		return
+	}
+
+	return kp.Address(), nil
+}
+
+// Label returns the label of the address; it is empty if not found.
+func (b AddressBook) Label(address string) string {
+	return b.labels[address]
+}
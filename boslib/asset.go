@@ -0,0 +1,89 @@
+package boslib
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+// ParseAsset parses the asset string; "native" or "XLM" for lumen and
+// "<code>:<issuer address>" for credit asset.
+func ParseAsset(s string) (asset b.Asset, err error) {
+	s = strings.TrimSpace(s)
+	if strings.ToLower(s) == "native" || s == "XLM" {
+		return b.NativeAsset(), nil
+	}
+
+	a := strings.SplitN(s, ":", 2)
+	if len(a) != 2 {
+		err = fmt.Errorf("invalid asset, '%s'; must be 'native' or '<code>:<issuer>'", s)
+		return
+	}
+
+	code, issuer := strings.TrimSpace(a[0]), strings.TrimSpace(a[1])
+	if len(code) < 1 || len(code) > 12 {
+		err = fmt.Errorf("invalid asset code, '%s'; length must be 1 to 12", code)
+		return
+	}
+	if _, err = keypair.Parse(issuer); err != nil {
+		err = fmt.Errorf("invalid asset issuer, '%s': %v", issuer, err)
+		return
+	}
+
+	return b.CreditAsset(code, issuer), nil
+}
+
+// AssetFromHorizon makes asset from the 'asset_type', 'asset_code' and
+// 'asset_issuer' of horizon response.
+func AssetFromHorizon(assetType, code, issuer string) b.Asset {
+	if assetType == "native" {
+		return b.NativeAsset()
+	}
+
+	return b.CreditAsset(code, issuer)
+}
+
+func AssetString(asset b.Asset) string {
+	if asset.Native {
+		return "XLM"
+	}
+
+	return fmt.Sprintf("%s:%s", asset.Code, asset.Issuer)
+}
+
+func AssetEqual(a, c b.Asset) bool {
+	if a.Native || c.Native {
+		return a.Native == c.Native
+	}
+
+	return a.Code == c.Code && a.Issuer == c.Issuer
+}
+
+// AssetAmount makes the payment amount mutator for the given asset.
+func AssetAmount(asset b.Asset, amount string) interface{} {
+	if asset.Native {
+		return b.NativeAmount{Amount: amount}
+	}
+
+	return b.CreditAmount{Code: asset.Code, Issuer: asset.Issuer, Amount: amount}
+}
+
+// AssetQuery sets the asset parameters of horizon query like
+// '<prefix>_asset_type', '<prefix>_asset_code' and '<prefix>_asset_issuer'.
+func AssetQuery(q url.Values, prefix string, asset b.Asset) {
+	if asset.Native {
+		q.Set(prefix+"_asset_type", "native")
+		return
+	}
+
+	if len(asset.Code) > 4 {
+		q.Set(prefix+"_asset_type", "credit_alphanum12")
+	} else {
+		q.Set(prefix+"_asset_type", "credit_alphanum4")
+	}
+	q.Set(prefix+"_asset_code", asset.Code)
+	q.Set(prefix+"_asset_issuer", asset.Issuer)
+}
@@ -0,0 +1,37 @@
+package boslib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	b "github.com/stellar/go/build"
+)
+
+// NetworkName returns the well-known name of the network passphrase; the
+// unknown passphrase is returned as it is.
+func NetworkName(networkPassphrase string) string {
+	switch networkPassphrase {
+	case b.PublicNetwork.Passphrase:
+		return "public network"
+	case b.TestNetwork.Passphrase:
+		return "test network"
+	}
+
+	return fmt.Sprintf("'%s'", networkPassphrase)
+}
+
+// Ask prints the prompt and reads the answer from r. If nothing can be
+// read, like stdin is closed, it returns error.
+func Ask(r io.Reader, w io.Writer, prompt string) (answer string, err error) {
+	fmt.Fprint(w, prompt)
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) < 1) {
+		err = fmt.Errorf("failed to read the answer: %v", err)
+		return
+	}
+
+	return strings.TrimSpace(line), nil
+}
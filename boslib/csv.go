@@ -0,0 +1,220 @@
+package boslib
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CSVColumns are the known column names of csv header.
+var CSVColumns = []string{"address", "amount", "asset", "memo", "memo_type", "label"}
+
+// CSVRecord is the row of csv file by column names.
+type CSVRecord struct {
+	Line     int
+	Address  string
+	Amount   string
+	Asset    string
+	Memo     string
+	MemoType string
+	Label    string
+}
+
+func (r *CSVRecord) set(column, value string) {
+	switch column {
+	case "address":
+		r.Address = value
+	case "amount":
+		r.Amount = value
+	case "asset":
+		r.Asset = value
+	case "memo":
+		r.Memo = value
+	case "memo_type":
+		r.MemoType = value
+	case "label":
+		r.Label = value
+	}
+}
+
+// CSVError is the error of csv file with the line number.
+type CSVError struct {
+	Line int
+	err  error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.err)
+}
+
+// ReadCSV reads the csv file. See ParseCSV.
+func ReadCSV(name string, required int, columns ...string) (records []CSVRecord, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	defer f.Close()
+
+	var comma rune
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tsv", ".tab":
+		comma = '\t'
+	}
+
+	return ParseCSV(f, comma, required, columns...)
+}
+
+// ParseCSV parses the csv. If all the fields of the first row are the known
+// column names, it is header and the columns are decided by it, if not, the
+// columns are decided by the given columns by order; the header can not have
+// the columns, which are not in the given columns and it must have the first
+// required columns of the given columns. The empty lines and the lines
+// started with '#' are skipped and the fields can be quoted. If comma is 0,
+// tab is used when the first row has tab and no comma.
+func ParseCSV(r io.Reader, comma rune, required int, columns ...string) (records []CSVRecord, err error) {
+	br := bufio.NewReader(r)
+
+	var line, start int
+	var chunk string
+	var header bool
+	for {
+		l, e := br.ReadString('\n')
+		if e != nil && e != io.EOF {
+			err = e
+			return
+		}
+		if len(l) < 1 && e == io.EOF {
+			break
+		}
+		line++
+
+		// BOM
+		if line == 1 {
+			l = strings.TrimPrefix(l, "\ufeff")
+		}
+
+		if len(chunk) < 1 {
+			start = line
+			if t := strings.TrimSpace(l); len(t) < 1 || strings.HasPrefix(t, "#") {
+				if e == io.EOF {
+					break
+				}
+				continue
+			}
+		}
+		chunk += l
+
+		// the quoted field can have new lines
+		if strings.Count(chunk, `"`)%2 != 0 && e != io.EOF {
+			continue
+		}
+
+		if comma == 0 {
+			comma = ','
+			if strings.Contains(chunk, "\t") && !strings.Contains(chunk, ",") {
+				comma = '\t'
+			}
+		}
+
+		cr := csv.NewReader(strings.NewReader(chunk))
+		cr.Comma = comma
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		chunk = ""
+
+		var fields []string
+		if fields, err = cr.Read(); err != nil {
+			err = &CSVError{Line: start, err: err}
+			return
+		}
+		trimmed := make([]string, len(fields))
+		for i := range fields {
+			trimmed[i] = strings.TrimSpace(fields[i])
+		}
+
+		if len(records) < 1 && !header && isCSVHeader(trimmed) {
+			if columns, err = parseCSVHeader(trimmed, columns, columns[:required]); err != nil {
+				err = &CSVError{Line: start, err: err}
+				return
+			}
+			header = true
+		} else {
+			if len(fields) > len(columns) {
+				err = &CSVError{Line: start, err: fmt.Errorf("too many columns, %d; expected %d", len(fields), len(columns))}
+				return
+			}
+
+			record := CSVRecord{Line: start}
+			for i, f := range trimmed {
+				// the spaces of text memo are kept
+				if columns[i] == "memo" {
+					f = fields[i]
+				}
+				record.set(columns[i], f)
+			}
+			records = append(records, record)
+		}
+
+		if e == io.EOF {
+			break
+		}
+	}
+
+	return
+}
+
+func hasCSVColumn(columns []string, name string) bool {
+	for _, c := range columns {
+		if strings.ToLower(name) == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCSVHeader checks whether all the fields are the known column names.
+func isCSVHeader(fields []string) bool {
+	for _, f := range fields {
+		if !hasCSVColumn(CSVColumns, f) {
+			return false
+		}
+	}
+
+	return len(fields) > 0
+}
+
+// parseCSVHeader decides the columns by header; the column, which is not in
+// the allowed columns, like 'asset' for creating account, is rejected and the
+// required columns must be in header. The 'label' is only for the reader of
+// file, so it is always allowed.
+func parseCSVHeader(fields, allowed, required []string) (columns []string, err error) {
+	seen := map[string]bool{}
+	for _, f := range fields {
+		name := strings.ToLower(f)
+
+		if name != "label" && !hasCSVColumn(allowed, name) {
+			err = fmt.Errorf("unknown column, '%s' in header; must be one of %s", f, strings.Join(allowed, ", "))
+			return
+		} else if seen[name] {
+			err = fmt.Errorf("column, '%s' is duplicated in header", f)
+			return
+		}
+		seen[name] = true
+
+		columns = append(columns, name)
+	}
+
+	for _, c := range required {
+		if !seen[c] {
+			err = fmt.Errorf("column, '%s' is missing in header", c)
+			return
+		}
+	}
+
+	return
+}
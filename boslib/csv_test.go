@@ -0,0 +1,113 @@
+package boslib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	columns := []string{"address", "amount", "memo", "memo_type"}
+
+	cases := []struct {
+		name    string
+		csv     string
+		comma   rune
+		records []CSVRecord
+		err     string
+	}{
+		{
+			name:    "without header",
+			csv:     "GA,1\nGB,2,hi,text\n",
+			records: []CSVRecord{{Line: 1, Address: "GA", Amount: "1"}, {Line: 2, Address: "GB", Amount: "2", Memo: "hi", MemoType: "text"}},
+		},
+		{
+			name:    "header in any order",
+			csv:     "amount,address,label\n1,GA,alice\n",
+			records: []CSVRecord{{Line: 2, Address: "GA", Amount: "1", Label: "alice"}},
+		},
+		{
+			name:    "bom",
+			csv:     "\ufeffaddress,amount\nGA,1\n",
+			records: []CSVRecord{{Line: 2, Address: "GA", Amount: "1"}},
+		},
+		{
+			name:    "comments and empty lines",
+			csv:     "# accounts\n\naddress,amount\n  # skipped\nGA,1\n\n",
+			records: []CSVRecord{{Line: 5, Address: "GA", Amount: "1"}},
+		},
+		{
+			name:    "quoted new line",
+			csv:     "GA,1,\"first\nsecond\"\nGB,2\n",
+			records: []CSVRecord{{Line: 1, Address: "GA", Amount: "1", Memo: "first\nsecond"}, {Line: 3, Address: "GB", Amount: "2"}},
+		},
+		{
+			name:    "tsv detected",
+			csv:     "GA\t1\thello world\n",
+			records: []CSVRecord{{Line: 1, Address: "GA", Amount: "1", Memo: "hello world"}},
+		},
+		{
+			name:    "tsv given",
+			csv:     "GA\t1\ta,b\n",
+			comma:   '\t',
+			records: []CSVRecord{{Line: 1, Address: "GA", Amount: "1", Memo: "a,b"}},
+		},
+		{
+			name:    "memo with spaces",
+			csv:     " GA , 1 ,  two spaces  , text \r\n",
+			records: []CSVRecord{{Line: 1, Address: "GA", Amount: "1", Memo: "  two spaces  ", MemoType: "text"}},
+		},
+		{
+			name:    "not header by unknown field",
+			csv:     "address,amount,note\n",
+			records: []CSVRecord{{Line: 1, Address: "address", Amount: "amount", Memo: "note"}},
+		},
+		{
+			name: "unknown column",
+			csv:  "address,amount,asset\nGA,1,native\n",
+			err:  "line 1: unknown column, 'asset' in header",
+		},
+		{
+			name: "duplicated column",
+			csv:  "address,amount,address\n",
+			err:  "line 1: column, 'address' is duplicated in header",
+		},
+		{
+			name: "missing required column",
+			csv:  "amount,memo\n1,hi\n",
+			err:  "line 1: column, 'address' is missing in header",
+		},
+		{
+			name: "too many fields",
+			csv:  "GA,1,hi,text,extra\n",
+			err:  "line 1: too many columns, 5; expected 4",
+		},
+		{
+			name: "too many fields with header",
+			csv:  "address,amount\nGA,1,hi\n",
+			err:  "line 2: too many columns, 3; expected 2",
+		},
+	}
+
+	for _, c := range cases {
+		records, err := ParseCSV(strings.NewReader(c.csv), c.comma, 2, columns...)
+		if len(c.err) > 0 {
+			if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+				t.Errorf("%s: error, %v; expected '%s'", c.name, err, c.err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+
+		if len(records) != len(c.records) {
+			t.Errorf("%s: %d records; expected %d: %+v", c.name, len(records), len(c.records), records)
+			continue
+		}
+		for i, r := range records {
+			if r != c.records[i] {
+				t.Errorf("%s: record %d, %+v; expected %+v", c.name, i, r, c.records[i])
+			}
+		}
+	}
+}
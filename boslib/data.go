@@ -0,0 +1,48 @@
+package boslib
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxDataLength is the maximum length of the name and value of data entry.
+const MaxDataLength = 64
+
+// DecodeDataValue decodes the base64 encoded value of data entry in horizon
+// response.
+func DecodeDataValue(v string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// FormatDataValue returns the printable text value as it is, otherwise hex
+// string prefixed with 'hex:'.
+func FormatDataValue(v []byte) string {
+	if utf8.Valid(v) {
+		printable := true
+		for _, r := range string(v) {
+			if !unicode.IsPrint(r) {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return string(v)
+		}
+	}
+
+	return "hex:" + hex.EncodeToString(v)
+}
+
+func CheckDataEntry(name string, value []byte) error {
+	if len(name) < 1 || len(name) > MaxDataLength {
+		return fmt.Errorf("invalid data name, '%s'; length must be 1 to %d", name, MaxDataLength)
+	}
+	if len(value) > MaxDataLength {
+		return fmt.Errorf("too long data value, %d bytes; must be shorter than %d bytes", len(value), MaxDataLength+1)
+	}
+
+	return nil
+}
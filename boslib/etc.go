@@ -1,6 +1,8 @@
 package boslib
 
 import (
+	"encoding/json"
+	"net"
 	"net/http"
 	"time"
 
@@ -9,8 +11,7 @@ import (
 	"github.com/stellar/go/xdr"
 )
 
-var DefaultFee uint64 = 10000    // default value of boscoin, stroop
-var MinimumBalance float64 = 0.1 // minimum balance for account, XLM
+var DefaultFee uint64 = 10000 // default value of boscoin, stroop
 
 type FixedSequence struct {
 	Seq xdr.SequenceNumber
@@ -36,3 +37,109 @@ type SigningError struct {
 func (s *SigningError) Error() string {
 	return s.err.Error()
 }
+
+// causeOf returns the underlying error of the wrapped error.
+func causeOf(err error) error {
+	for {
+		c, ok := err.(interface {
+			Cause() error
+		})
+		if !ok {
+			return err
+		}
+		err = c.Cause()
+	}
+}
+
+type TransactionResultCodes struct {
+	TransactionCode string   `json:"transaction"`
+	OperationCodes  []string `json:"operations"`
+}
+
+// ResultCodes extracts the result codes from the error of submitting
+// transaction. If the error is not from horizon, found is false.
+func ResultCodes(err error) (codes TransactionResultCodes, found bool) {
+	herr, ok := causeOf(err).(*horizon.Error)
+	if !ok {
+		return
+	}
+
+	raw, ok := herr.Problem.Extras["result_codes"]
+	if !ok {
+		return
+	}
+
+	if e := json.Unmarshal(raw, &codes); e != nil {
+		return
+	}
+
+	return codes, true
+}
+
+// HasOperationCode checks whether the error of submitting transaction has
+// the given operation result code.
+func HasOperationCode(err error, code string) bool {
+	codes, found := ResultCodes(err)
+	if !found {
+		return false
+	}
+
+	for _, c := range codes.OperationCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FeeCharged extracts the fee charged from the error of submitting
+// transaction. Only the failed transaction by 'tx_failed' was applied in
+// ledger, so the fee was charged.
+func FeeCharged(err error) (fee xdr.Int64, charged bool) {
+	codes, found := ResultCodes(err)
+	if !found || codes.TransactionCode != "tx_failed" {
+		return
+	}
+
+	var resultXDR string
+	if e := json.Unmarshal(causeOf(err).(*horizon.Error).Problem.Extras["result_xdr"], &resultXDR); e != nil {
+		return
+	}
+
+	var result xdr.TransactionResult
+	if e := xdr.SafeUnmarshalBase64(resultXDR, &result); e != nil {
+		return
+	}
+
+	return result.FeeCharged, true
+}
+
+// IsTransientError checks whether the error of submitting transaction is
+// temporary, so it can be submitted again.
+func IsTransientError(err error) bool {
+	if _, ok := causeOf(err).(net.Error); ok {
+		return true
+	}
+
+	herr, ok := causeOf(err).(*horizon.Error)
+	if !ok {
+		return false
+	}
+
+	if herr.Problem.Status >= 500 || herr.Problem.Status == 429 {
+		return true
+	}
+
+	codes, found := ResultCodes(err)
+	if !found {
+		return false
+	}
+
+	switch codes.TransactionCode {
+	case "tx_bad_seq", "tx_too_late", "tx_insufficient_fee":
+		return true
+	}
+
+	return false
+}
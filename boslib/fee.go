@@ -0,0 +1,265 @@
+package boslib
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+const DefaultFeePercentile = 70
+
+var DefaultMaxFee uint64 = 100000 // maximum fee per operation of '-fee auto', stroop
+
+// FullCapacityUsage is the capacity usage of ledger, over which the
+// transactions compete by fee; under it, the base fee is enough.
+const FullCapacityUsage = 0.8
+
+// FeePercentiles are the percentiles of the fee stats of horizon.
+var FeePercentiles = []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 95, 99}
+
+// FeeStats is the fee per operation of the recent transactions.
+type FeeStats struct {
+	LastLedger    int32
+	BaseFee       uint64
+	CapacityUsage float64
+	Percentiles   map[int]uint64
+}
+
+// LoadFeeStats loads the fee stats from '/fee_stats' of horizon. If horizon
+// does not support it, the fee stats are calculated from the recent
+// transactions.
+func LoadFeeStats(horizonUrl string) (stats FeeStats, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "fee_stats")
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode == 404 {
+		log.Debugf("'%s' is not supported; fee stats are calculated from the recent transactions", u.String())
+		return loadFeeStatsFromTransactions(horizonUrl)
+	} else if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get fee stats from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	// the numbers are string or number by the version of horizon
+	var skel map[string]interface{}
+	if err = json.Unmarshal(body, &skel); err != nil {
+		err = fmt.Errorf("invalid fee stats received: %v", err)
+		return
+	}
+
+	number := func(k string) (f float64, err error) {
+		switch v := skel[k].(type) {
+		case float64:
+			return v, nil
+		case string:
+			if f, err = strconv.ParseFloat(v, 64); err == nil {
+				return
+			}
+		}
+
+		err = fmt.Errorf("invalid fee stats received; '%s' is %v", k, skel[k])
+		return
+	}
+
+	var f float64
+	if f, err = number("last_ledger"); err != nil {
+		return
+	}
+	stats.LastLedger = int32(f)
+
+	if f, err = number("last_ledger_base_fee"); err != nil {
+		return
+	}
+	stats.BaseFee = uint64(f)
+
+	if stats.CapacityUsage, err = number("ledger_capacity_usage"); err != nil {
+		return
+	}
+
+	stats.Percentiles = map[int]uint64{}
+	for _, p := range FeePercentiles {
+		if f, err = number(fmt.Sprintf("p%d_accepted_fee", p)); err != nil {
+			return
+		}
+		stats.Percentiles[p] = uint64(f)
+	}
+
+	return
+}
+
+// loadFeeStatsFromTransactions calculates the fee stats from the latest
+// ledger and the fees of the recent transactions.
+func loadFeeStatsFromTransactions(horizonUrl string) (stats FeeStats, err error) {
+	ledger, err := LoadLatestLedger(horizonUrl)
+	if err != nil {
+		return
+	}
+
+	stats.LastLedger = ledger.Sequence
+	stats.BaseFee = uint64(ledger.BaseFee)
+	if ledger.MaxTxSetSize > 0 {
+		stats.CapacityUsage = float64(ledger.TransactionCount) / float64(ledger.MaxTxSetSize)
+	}
+
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "transactions")
+	u.RawQuery = url.Values{"order": []string{"desc"}, "limit": []string{"200"}}.Encode()
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get transactions from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	var skel struct {
+		Embedded struct {
+			Records []TransactionRecord `json:"records"`
+		} `json:"_embedded"`
+	}
+	if err = json.Unmarshal(body, &skel); err != nil {
+		err = fmt.Errorf("invalid transactions received: %v", err)
+		return
+	}
+
+	var fees []uint64
+	for _, r := range skel.Embedded.Records {
+		if r.OperationCount < 1 {
+			continue
+		}
+		fees = append(fees, uint64(r.FeePaid)/uint64(r.OperationCount))
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+
+	stats.Percentiles = map[int]uint64{}
+	for _, p := range FeePercentiles {
+		if len(fees) < 1 {
+			stats.Percentiles[p] = stats.BaseFee
+			continue
+		}
+
+		i := len(fees) * p / 100
+		if i >= len(fees) {
+			i = len(fees) - 1
+		}
+		stats.Percentiles[p] = fees[i]
+	}
+
+	return
+}
+
+// ChooseFee chooses the fee per operation by the percentile of the fee stats.
+// If the ledgers are not full, the base fee is enough.
+func ChooseFee(stats FeeStats, percentile int, maxFee uint64) (fee uint64, err error) {
+	fee, found := stats.Percentiles[percentile]
+	if !found {
+		err = fmt.Errorf("invalid percentile, %d; must be one of %v", percentile, FeePercentiles)
+		return
+	}
+
+	if stats.CapacityUsage < FullCapacityUsage || fee < stats.BaseFee {
+		fee = stats.BaseFee
+	}
+
+	if fee > maxFee {
+		err = fmt.Errorf(
+			"fee, %d of %d percentile is higher than the maximum fee, %d; the capacity usage of ledger, %d is %.2f",
+			fee,
+			percentile,
+			maxFee,
+			stats.LastLedger,
+			stats.CapacityUsage,
+		)
+		return
+	}
+
+	return
+}
+
+// ResolveFee parses the '-fee' of commands; it is the fee in stroop or
+// 'auto'. With 'auto', the fee is chosen from the fee stats of horizon.
+func ResolveFee(horizonUrl, s string, percentile int, maxFee uint64) (fee uint64, auto bool, err error) {
+	s = strings.TrimSpace(s)
+	if s != "auto" {
+		if fee, err = strconv.ParseUint(s, 10, 64); err != nil {
+			err = fmt.Errorf("invalid -fee, '%s'; must be stroop or 'auto'", s)
+		}
+		return
+	}
+
+	var stats FeeStats
+	if stats, err = LoadFeeStats(horizonUrl); err != nil {
+		return
+	}
+
+	fee, err = ChooseFee(stats, percentile, maxFee)
+	log.Debugf("fee, %d was chosen from fee stats: %+v", fee, stats)
+
+	return fee, true, err
+}
+
+// FeeFlags is the flags of fee for the commands making transaction.
+type FeeFlags struct {
+	Fee        string
+	Percentile int
+	MaxFee     uint64
+}
+
+// SetFeeFlags adds -fee, -fee-percentile and -max-fee to the flag set.
+func SetFeeFlags(flags *flag.FlagSet) *FeeFlags {
+	f := &FeeFlags{}
+	flags.StringVar(&f.Fee, "fee", strconv.FormatUint(DefaultFee, 10), "transaction fee per operation, stroop or 'auto'")
+	flags.IntVar(&f.Percentile, "fee-percentile", DefaultFeePercentile, "percentile of the recent fees for '-fee auto'")
+	flags.Uint64Var(&f.MaxFee, "max-fee", DefaultMaxFee, "maximum fee per operation for '-fee auto'")
+
+	return f
+}
+
+// Resolve returns the fee per operation by the flags, see ResolveFee. With
+// 'auto', the fee is chosen by the fee stats at the time, so the long
+// running command resolves it again before submitting.
+func (f *FeeFlags) Resolve(horizonUrl string) (fee uint64, auto bool, err error) {
+	return ResolveFee(horizonUrl, f.Fee, f.Percentile, f.MaxFee)
+}
+
+// FeeSummary shows the total fee of operations.
+func FeeSummary(fee uint64, operations int) string {
+	return fmt.Sprintf(
+		"%d stroops x %d operations = %s",
+		fee,
+		operations,
+		amount.String(xdr.Int64(fee)*xdr.Int64(operations)),
+	)
+}
@@ -0,0 +1,109 @@
+package boslib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistoryKinds are the collections of account history in horizon.
+var HistoryKinds = []string{"transactions", "operations", "payments", "effects"}
+
+// HistoryRecord has the common fields of the records of transactions,
+// operations, payments and effects; the fields, which are not in the record,
+// are empty.
+type HistoryRecord struct {
+	ID          string `json:"id"`
+	PagingToken string `json:"paging_token"`
+	Type        string `json:"type"`
+	CreatedAt   string `json:"created_at"`
+
+	// transaction
+	Hash           string `json:"hash"`
+	Ledger         int32  `json:"ledger"`
+	SourceAccount  string `json:"source_account"`
+	FeePaid        int64  `json:"fee_paid"`
+	OperationCount int32  `json:"operation_count"`
+	MemoType       string `json:"memo_type"`
+	Memo           string `json:"memo"`
+
+	// operation, payment and effect
+	TransactionHash string `json:"transaction_hash"`
+	Account         string `json:"account"`
+	Funder          string `json:"funder"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Amount          string `json:"amount"`
+	StartingBalance string `json:"starting_balance"`
+	AssetType       string `json:"asset_type"`
+	AssetCode       string `json:"asset_code"`
+	AssetIssuer     string `json:"asset_issuer"`
+}
+
+// IsTransaction checks whether the record is transaction; the transaction
+// record does not have type.
+func (r HistoryRecord) IsTransaction() bool {
+	return len(r.Type) < 1 && len(r.Hash) > 0
+}
+
+// Time is the time when the record was created; found is false if the
+// record does not have 'created_at', like the effects of old horizon.
+func (r HistoryRecord) Time() (t time.Time, found bool) {
+	if len(r.CreatedAt) < 1 {
+		return
+	}
+
+	var err error
+	if t, err = time.Parse(time.RFC3339, r.CreatedAt); err != nil {
+		return
+	}
+
+	return t, true
+}
+
+// Summary is the one line summary of the record.
+func (r HistoryRecord) Summary() string {
+	if r.IsTransaction() {
+		s := fmt.Sprintf(
+			"%s transaction %s in ledger, %d: %d operations, fee %d",
+			r.CreatedAt,
+			r.Hash,
+			r.Ledger,
+			r.OperationCount,
+			r.FeePaid,
+		)
+		if len(r.MemoType) > 0 && r.MemoType != MemoTypeNone {
+			s += fmt.Sprintf(", memo %s:%s", r.MemoType, r.Memo)
+		}
+		return s
+	}
+
+	l := []string{r.CreatedAt, r.Type}
+
+	a := r.Amount
+	if len(a) < 1 {
+		a = r.StartingBalance
+	}
+	if len(a) > 0 {
+		asset := "XLM"
+		if len(r.AssetType) > 0 && r.AssetType != "native" {
+			asset = AssetString(AssetFromHorizon(r.AssetType, r.AssetCode, r.AssetIssuer))
+		}
+		l = append(l, a, asset)
+	}
+
+	switch {
+	case len(r.From) > 0 || len(r.To) > 0:
+		l = append(l, fmt.Sprintf("%s -> %s", r.From, r.To))
+	case len(r.Funder) > 0:
+		l = append(l, fmt.Sprintf("%s -> %s", r.Funder, r.Account))
+	case len(r.Account) > 0:
+		l = append(l, r.Account)
+	}
+
+	if len(r.TransactionHash) > 0 {
+		l = append(l, "tx", r.TransactionHash)
+	}
+
+	return strings.Join(l, " ")
+}
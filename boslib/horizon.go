@@ -0,0 +1,58 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// CheckHorizon checks whether the given url is horizon and returns the
+// network passphrase of it.
+func CheckHorizon(horizonUrl string) (networkPassphrase string, err error) {
+	horizonUrl = strings.TrimSpace(horizonUrl)
+	if len(horizonUrl) < 1 {
+		err = fmt.Errorf("--horizon must be given")
+		return
+	}
+
+	response, err := http.Get(horizonUrl)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", horizonUrl, err)
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", horizonUrl, response.StatusCode)
+		return
+	}
+
+	if h, found := response.Header["Content-Type"]; !found {
+		err = fmt.Errorf("wrong horizon, '%s': 'Content-Type' is missing", horizonUrl)
+		return
+	} else if strings.Split(h[0], ";")[0] != "application/hal+json" {
+		err = fmt.Errorf("wrong horizon, '%s': 'Content-Type' is not 'application/hal+json'", horizonUrl)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		err = fmt.Errorf("wrong horizon, '%s': %v", horizonUrl, err)
+		return
+	}
+
+	var apiSkel map[string]interface{}
+	json.Unmarshal(body, &apiSkel)
+
+	if p, found := apiSkel["network_passphrase"]; !found {
+		err = fmt.Errorf("wrong horizon, '%s': 'network_passphrase' is missing in response", horizonUrl)
+		return
+	} else if networkPassphrase, found = p.(string); !found {
+		err = fmt.Errorf("wrong horizon, '%s': invalid 'network_passphrase', %v", horizonUrl, p)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,157 @@
+package boslib
+
+import (
+	"sort"
+	"time"
+
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+// InflationWinnerRatio is the minimum ratio of votes to the total coins to
+// win the inflation, 0.05%.
+const InflationWinnerRatio = 0.0005
+
+func SetInflationDestination(horizonUrl, senderSeed, destination, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, muts ...b.TransactionMutator) (
+	resp horizon.TransactionSuccess,
+	err error,
+) {
+	return SubmitOperations(
+		horizonUrl,
+		senderSeed,
+		networkPassphrase,
+		seq,
+		fee,
+		append(muts, b.SetOptions(b.InflationDest(destination)))...,
+	)
+}
+
+type InflationVoter struct {
+	Address string
+	Balance xdr.Int64
+}
+
+type InflationVotes struct {
+	Destination string
+	Voters      []InflationVoter
+	Total       xdr.Int64
+	Threshold   xdr.Int64 // minimum votes to win the inflation
+}
+
+func (v InflationVotes) IsWinner() bool {
+	return v.Total >= v.Threshold
+}
+
+// CountInflationVotes counts the votes for the destination from the given
+// accounts; horizon does not support to find the accounts by inflation
+// destination, so the candidates must be given.
+func CountInflationVotes(destination string, accounts []Account, totalCoins string) (votes InflationVotes, err error) {
+	votes.Destination = destination
+
+	var coins xdr.Int64
+	if coins, err = amount.Parse(totalCoins); err != nil {
+		return
+	}
+	votes.Threshold = xdr.Int64(float64(coins) * InflationWinnerRatio)
+
+	for _, a := range accounts {
+		if a.InflationDestination != destination {
+			continue
+		}
+
+		var balance xdr.Int64
+		if balance, err = amount.Parse(a.NativeBalance()); err != nil {
+			return
+		}
+
+		votes.Voters = append(votes.Voters, InflationVoter{Address: a.ID, Balance: balance})
+		votes.Total += balance
+	}
+
+	sort.SliceStable(votes.Voters, func(i, j int) bool {
+		return votes.Voters[i].Balance > votes.Voters[j].Balance
+	})
+
+	return
+}
+
+// InflationStartTime is the time of the first inflation, 2014-07-01 00:00:00
+// UTC; the inflation can run once a week from it.
+var InflationStartTime = time.Unix(1404172800, 0)
+
+const InflationFrequency = 7 * 24 * time.Hour
+
+// NextInflationTime returns the next time, when the inflation can run after
+// the inflation ran at the given time.
+func NextInflationTime(last time.Time) time.Time {
+	weeks := last.Sub(InflationStartTime) / InflationFrequency
+	return InflationStartTime.Add((weeks + 1) * InflationFrequency)
+}
+
+// LastInflationTime returns the last time, when the inflation could run at
+// or before the given time.
+func LastInflationTime(t time.Time) time.Time {
+	weeks := t.Sub(InflationStartTime) / InflationFrequency
+	return InflationStartTime.Add(weeks * InflationFrequency)
+}
+
+// FindInflationSince finds the inflation operation in the ledgers closed at
+// or after the time. The operations are searched from the time, so the
+// inflation, which usually runs right after the inflation time, is found
+// soon.
+func FindInflationSince(horizonUrl string, since time.Time) (hash string, found bool, err error) {
+	ledger, found, err := FindLedgerAt(horizonUrl, since)
+	if err != nil || !found {
+		return
+	}
+
+	p := NewPager(
+		"operations",
+		CollectionURL(horizonUrl, PageQuery{Cursor: ledger.PagingToken, Order: "asc", Limit: MaxPageLimit}, "operations"),
+	)
+	for p.Next() {
+		var r struct {
+			Type            string `json:"type"`
+			TransactionHash string `json:"transaction_hash"`
+		}
+		if err = p.Decode(&r); err != nil {
+			return
+		}
+
+		if r.Type == "inflation" {
+			return r.TransactionHash, true, nil
+		}
+	}
+	err = p.Err()
+
+	return "", false, err
+}
+
+type InflationPayout struct {
+	Account string `json:"account"`
+	Amount  string `json:"amount"`
+}
+
+// LoadInflationPayouts loads the payouts, 'account_credited' effects of the
+// inflation transaction.
+func LoadInflationPayouts(horizonUrl, hash string) (payouts []InflationPayout, err error) {
+	p := NewPager("effects", CollectionURL(horizonUrl, PageQuery{Limit: MaxPageLimit}, "transactions", hash, "effects"))
+	for p.Next() {
+		var r struct {
+			Type string `json:"type"`
+			InflationPayout
+		}
+		if err = p.Decode(&r); err != nil {
+			return
+		}
+
+		if r.Type == "account_credited" {
+			payouts = append(payouts, r.InflationPayout)
+		}
+	}
+
+	err = p.Err()
+	return
+}
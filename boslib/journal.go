@@ -0,0 +1,405 @@
+package boslib
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	JournalPlanned    = "planned"
+	JournalSubmitting = "submitting"
+	JournalSuccess    = "success"
+	JournalFailed     = "failed"
+)
+
+// JournalReject is the row removed from batch by the failed operation.
+type JournalReject struct {
+	Balance
+	Reason string `json:"reason"`
+}
+
+// JournalEntry is the state of one batch. The entries are appended to the
+// journal file, so the last entry of batch is the current state of it. Fee is
+// the fee charged for the last transaction and TotalFee is the sum of fees of
+// all the applied transactions of batch, including the failed ones. Codes are
+// the operation result codes of the last failed transaction. Batches is the
+// number of batches of the plan, see Journal.Complete.
+type JournalEntry struct {
+	Batch    int             `json:"batch"`
+	Batches  int             `json:"batches,omitempty"`
+	Status   string          `json:"status"`
+	Rows     []Balance       `json:"rows,omitempty"`
+	Rejects  []JournalReject `json:"rejects,omitempty"`
+	Hash     string          `json:"hash,omitempty"`
+	Envelope string          `json:"envelope,omitempty"`
+	Ledger   int32           `json:"ledger,omitempty"`
+	Fee      int64           `json:"fee,omitempty"`
+	TotalFee int64           `json:"total_fee,omitempty"`
+	Codes    []string        `json:"codes,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Time     time.Time       `json:"time"`
+}
+
+// Remaining returns the rows of batch except the rejected rows.
+func (e JournalEntry) Remaining() (rows []Balance) {
+	rejected := map[int]bool{}
+	for _, r := range e.Rejects {
+		rejected[r.Line] = true
+	}
+
+	for _, r := range e.Rows {
+		if !rejected[r.Line] {
+			rows = append(rows, r)
+		}
+	}
+
+	return
+}
+
+// Journal records the state of batches, so the interrupted job can be
+// resumed.
+type Journal struct {
+	sync.Mutex
+	f       *os.File
+	entries map[int]JournalEntry
+}
+
+// OpenJournal opens the journal file and loads the existing entries; if the
+// file does not exist, it will be created.
+func OpenJournal(name string) (journal *Journal, err error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+
+	journal = &Journal{f: f, entries: map[int]JournalEntry{}}
+	if err = journal.load(f); err != nil {
+		f.Close()
+		return
+	}
+
+	return
+}
+
+// ReadJournal loads the entries of the journal file, but it can not be
+// written, like for dry run; if the file does not exist, the journal is empty
+// and the file is not created.
+func ReadJournal(name string) (journal *Journal, err error) {
+	journal = &Journal{entries: map[int]JournalEntry{}}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return journal, nil
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+
+	err = journal.load(f)
+
+	return
+}
+
+// load reads the entries from the journal file.
+func (j *Journal) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	var line int
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) < 1 {
+			continue
+		}
+
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("invalid journal entry at line, %d: %v", line, err)
+		}
+
+		// the planned rows are kept in the later entries
+		if p, found := j.entries[e.Batch]; found && len(e.Rows) < 1 {
+			e.Rows = p.Rows
+		}
+		j.entries[e.Batch] = e
+	}
+
+	return scanner.Err()
+}
+
+// Write appends the entry to the journal file and syncs it.
+func (j *Journal) Write(e JournalEntry) error {
+	j.Lock()
+	defer j.Unlock()
+
+	if j.f == nil {
+		return errors.New("journal is read only")
+	}
+
+	e.Time = time.Now()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err = j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err = j.f.Sync(); err != nil {
+		return err
+	}
+
+	if p, found := j.entries[e.Batch]; found && len(e.Rows) < 1 {
+		e.Rows = p.Rows
+	}
+	j.entries[e.Batch] = e
+
+	return nil
+}
+
+// Reject removes the rows from batch; the batch will be submitted again
+// without them.
+func (j *Journal) Reject(batch int, rejects ...JournalReject) error {
+	e, found := j.Entry(batch)
+	if !found {
+		return fmt.Errorf("batch, %d is not in journal", batch)
+	}
+
+	e.Rejects = append(e.Rejects, rejects...)
+	e.Status = JournalPlanned
+	e.Hash = ""
+	e.Envelope = ""
+
+	return j.Write(e)
+}
+
+// Entry returns the current state of batch.
+func (j *Journal) Entry(batch int) (JournalEntry, bool) {
+	j.Lock()
+	defer j.Unlock()
+
+	e, found := j.entries[batch]
+	return e, found
+}
+
+// Entries returns the current states of all batches, sorted by batch.
+func (j *Journal) Entries() (entries []JournalEntry) {
+	j.Lock()
+	defer j.Unlock()
+
+	for _, e := range j.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, k int) bool {
+		return entries[i].Batch < entries[k].Batch
+	})
+
+	return
+}
+
+// Complete checks whether all the batches of the plan are in journal; if the
+// planning was interrupted while writing the batches, it is not complete.
+func (j *Journal) Complete() bool {
+	j.Lock()
+	defer j.Unlock()
+
+	if len(j.entries) < 1 {
+		return false
+	}
+
+	for _, e := range j.entries {
+		if e.Batches != len(j.entries) || e.Batch >= e.Batches {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Truncate removes all the entries from the journal file.
+func (j *Journal) Truncate() error {
+	j.Lock()
+	defer j.Unlock()
+
+	if j.f == nil {
+		return errors.New("journal is read only")
+	}
+
+	if err := j.f.Truncate(0); err != nil {
+		return err
+	}
+	if err := j.f.Sync(); err != nil {
+		return err
+	}
+	j.entries = map[int]JournalEntry{}
+
+	return nil
+}
+
+func (j *Journal) Close() error {
+	if j.f == nil {
+		return nil
+	}
+
+	return j.f.Close()
+}
+
+// SubmitBatch submits the operations of batch and records the state of it
+// in journal. The batch already succeeded is skipped and the batch, which
+// was being submitted, is verified by hash; if it is not in ledger, the same
+// envelope is submitted again, so it can not be applied twice.
+func SubmitBatch(horizonUrl, senderSeed, networkPassphrase string, fee uint64, journal *Journal, batch int, ops ...b.TransactionMutator) (
+	entry JournalEntry,
+	err error,
+) {
+	return SubmitChannelBatch(horizonUrl, senderSeed, senderSeed, networkPassphrase, fee, journal, batch, ops...)
+}
+
+// SubmitChannelBatch is same with SubmitBatch, but the transaction is
+// submitted through the channel account.
+func SubmitChannelBatch(horizonUrl, channelSeed, senderSeed, networkPassphrase string, fee uint64, journal *Journal, batch int, ops ...b.TransactionMutator) (
+	entry JournalEntry,
+	err error,
+) {
+	entry, found := journal.Entry(batch)
+	if found && entry.Status == JournalSuccess {
+		log.Debugf("batch, %d already succeeded: %s", batch, entry.Hash)
+		return
+	}
+
+	entry.Batch = batch
+	entry.Error = ""
+	entry.Codes = nil
+
+	if found && entry.Status == JournalSubmitting && len(entry.Hash) > 0 {
+		var record TransactionRecord
+		var inLedger bool
+		if record, inLedger, err = LoadTransaction(horizonUrl, entry.Hash); err != nil {
+			return
+		}
+
+		if inLedger {
+			log.Debugf("batch, %d was already in ledger, %d: %s", batch, record.Ledger, entry.Hash)
+			err = writeApplied(journal, entry, record)
+			return
+		}
+
+		var resp horizon.TransactionSuccess
+		if resp, err = SubmitEnvelope(horizonUrl, entry.Envelope); err == nil {
+			err = writeSuccess(journal, entry, resp.Ledger, resultFee(resp.Result))
+			return
+		}
+
+		if codes, _ := ResultCodes(err); codes.TransactionCode != "tx_bad_seq" && codes.TransactionCode != "tx_too_late" {
+			writeFailure(journal, entry, err)
+			return
+		}
+
+		// the sequence of the envelope was used or the envelope was expired by
+		// time bounds; check once more, it may be applied just now.
+		if record, inLedger, err = LoadTransaction(horizonUrl, entry.Hash); err != nil {
+			return
+		} else if inLedger {
+			err = writeApplied(journal, entry, record)
+			return
+		}
+		log.Debugf("batch, %d was not applied; new transaction will be submitted", batch)
+	}
+
+	entry.Envelope, entry.Hash, err = BuildChannelTransaction(horizonUrl, channelSeed, senderSeed, networkPassphrase, 0, fee, ops...)
+	if err != nil {
+		return
+	}
+
+	entry.Status = JournalSubmitting
+	if err = journal.Write(entry); err != nil {
+		return
+	}
+
+	var resp horizon.TransactionSuccess
+	if resp, err = SubmitEnvelope(horizonUrl, entry.Envelope); err != nil {
+		writeFailure(journal, entry, err)
+		return
+	}
+
+	err = writeSuccess(journal, entry, resp.Ledger, resultFee(resp.Result))
+
+	return
+}
+
+// writeSuccess records the batch confirmed in ledger with the fee charged.
+func writeSuccess(journal *Journal, entry JournalEntry, ledger int32, fee int64) error {
+	entry.Status = JournalSuccess
+	entry.Ledger = ledger
+	entry.Fee = fee
+	entry.TotalFee += fee
+
+	return journal.Write(entry)
+}
+
+// writeApplied records the batch found in ledger by hash; the transaction
+// in ledger may be failed, then the batch is failed with the fee charged.
+func writeApplied(journal *Journal, entry JournalEntry, record TransactionRecord) error {
+	if record.Succeeded() {
+		return writeSuccess(journal, entry, record.Ledger, record.FeePaid)
+	}
+
+	err := fmt.Errorf("transaction, '%s' failed in ledger, %d", record.Hash, record.Ledger)
+
+	entry.Status = JournalFailed
+	entry.Ledger = record.Ledger
+	entry.Fee = record.FeePaid
+	entry.TotalFee += record.FeePaid
+	entry.Error = err.Error()
+	if e := journal.Write(entry); e != nil {
+		log.Errorf("failed to write journal: %v", e)
+	}
+
+	return err
+}
+
+// resultFee returns the fee charged from the result xdr of transaction.
+func resultFee(resultXDR string) int64 {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		log.Errorf("invalid result xdr, '%s': %v", resultXDR, err)
+		return 0
+	}
+
+	return int64(result.FeeCharged)
+}
+
+// writeFailure records the failure of batch. With the transient error like
+// timeout, the transaction may be applied, so the batch is kept in
+// 'submitting' to be verified by hash later.
+func writeFailure(journal *Journal, entry JournalEntry, err error) {
+	if IsTransientError(err) {
+		entry.Status = JournalSubmitting
+	} else {
+		entry.Status = JournalFailed
+	}
+	entry.Error = err.Error()
+
+	if fee, charged := FeeCharged(err); charged {
+		entry.Fee = int64(fee)
+		entry.TotalFee += int64(fee)
+	}
+	if codes, found := ResultCodes(err); found {
+		entry.Codes = codes.OperationCodes
+	}
+
+	if e := journal.Write(entry); e != nil {
+		log.Errorf("failed to write journal: %v", e)
+	}
+}
@@ -0,0 +1,201 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+type Ledger struct {
+	ID               string    `json:"id"`
+	PagingToken      string    `json:"paging_token"`
+	Hash             string    `json:"hash"`
+	Sequence         int32     `json:"sequence"`
+	ClosedAt         time.Time `json:"closed_at"`
+	TotalCoins       string    `json:"total_coins"`
+	FeePool          string    `json:"fee_pool"`
+	BaseFee          int32     `json:"base_fee_in_stroops"`
+	BaseReserve      int32     `json:"base_reserve_in_stroops"`
+	MaxTxSetSize     int32     `json:"max_tx_set_size"`
+	TransactionCount int32     `json:"transaction_count"`
+	OperationCount   int32     `json:"operation_count"`
+}
+
+// MinimumBalance is the lumens, which the account with the subentries must
+// keep by the base reserve; the new account has no subentries, so it must
+// be created with at least 2 x base reserve.
+func MinimumBalance(baseReserve xdr.Int64, subentries int32) xdr.Int64 {
+	return (2 + xdr.Int64(subentries)) * baseReserve
+}
+
+// MinimumBalance is the minimum balance of the account with the subentries
+// by the base reserve of the ledger.
+func (l Ledger) MinimumBalance(subentries int32) xdr.Int64 {
+	return MinimumBalance(xdr.Int64(l.BaseReserve), subentries)
+}
+
+// CheckFee checks the fee per operation is not lower than the base fee of
+// the ledger; the transaction with the lower fee is rejected by network.
+func (l Ledger) CheckFee(fee uint64) error {
+	if fee < uint64(l.BaseFee) {
+		return fmt.Errorf(
+			"fee, %d is lower than the base fee, %d of ledger, %d",
+			fee,
+			l.BaseFee,
+			l.Sequence,
+		)
+	}
+
+	return nil
+}
+
+// CheckSpendable checks the account can afford the required lumens, the
+// amounts and the fees, over its minimum balance by the base reserve of the
+// ledger.
+func (l Ledger) CheckSpendable(account Account, required xdr.Int64) error {
+	spendable := account.Spendable(xdr.Int64(l.BaseReserve))
+	if spendable < required {
+		return fmt.Errorf(
+			"insufficient balance of account, '%s'; %s is required, but only %s is spendable from %s over the minimum balance, %s",
+			account.ID,
+			amount.String(required),
+			amount.String(spendable),
+			account.NativeBalance(),
+			amount.String(account.MinimumBalance(xdr.Int64(l.BaseReserve))),
+		)
+	}
+
+	return nil
+}
+
+// LoadLatestLedger loads the last closed ledger from horizon.
+func LoadLatestLedger(horizonUrl string) (ledger Ledger, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "ledgers")
+	u.RawQuery = url.Values{"order": []string{"desc"}, "limit": []string{"1"}}.Encode()
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get ledgers from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	var skel struct {
+		Embedded struct {
+			Records []Ledger `json:"records"`
+		} `json:"_embedded"`
+	}
+	if err = json.Unmarshal(body, &skel); err != nil {
+		err = fmt.Errorf("invalid ledgers received: %v", err)
+		return
+	}
+
+	if len(skel.Embedded.Records) < 1 {
+		err = fmt.Errorf("empty ledgers received")
+		return
+	}
+
+	return skel.Embedded.Records[0], nil
+}
+
+// LoadLedger loads the ledger by sequence.
+func LoadLedger(horizonUrl string, sequence int32) (ledger Ledger, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "ledgers", strconv.FormatInt(int64(sequence), 10))
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode == 404 {
+		err = fmt.Errorf("ledger, %d does not exist", sequence)
+		return
+	} else if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get ledger from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	if err = json.Unmarshal(body, &ledger); err != nil {
+		err = fmt.Errorf("invalid ledger received: %v", err)
+		return
+	}
+
+	return
+}
+
+// FindLedgerAt finds the first ledger, which was closed at or after the
+// time; if the latest ledger was closed before it, found is false. The
+// sequence is estimated by the ledger close time, about 5 seconds, and found
+// by binary search.
+func FindLedgerAt(horizonUrl string, t time.Time) (ledger Ledger, found bool, err error) {
+	latest, err := LoadLatestLedger(horizonUrl)
+	if err != nil {
+		return
+	}
+	if latest.ClosedAt.Before(t) {
+		return
+	}
+
+	hi := latest
+	step := int32(latest.ClosedAt.Sub(t)/(5*time.Second)) + 1
+	var lo Ledger
+	for {
+		seq := hi.Sequence - step
+		if seq < 1 {
+			seq = 1
+		}
+		if lo, err = LoadLedger(horizonUrl, seq); err != nil {
+			return
+		}
+		if lo.ClosedAt.Before(t) {
+			break
+		}
+
+		hi = lo
+		if seq == 1 {
+			return hi, true, nil
+		}
+		step *= 2
+	}
+
+	for hi.Sequence-lo.Sequence > 1 {
+		var mid Ledger
+		if mid, err = LoadLedger(horizonUrl, lo.Sequence+(hi.Sequence-lo.Sequence)/2); err != nil {
+			return
+		}
+		if mid.ClosedAt.Before(t) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return hi, true, nil
+}
@@ -0,0 +1,141 @@
+package boslib
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	MemoTypeNone   = "none"
+	MemoTypeText   = "text"
+	MemoTypeID     = "id"
+	MemoTypeHash   = "hash"
+	MemoTypeReturn = "return"
+)
+
+const MaxMemoTextLength = 28 // bytes
+
+// Memo is the memo of transaction. The value of 'hash' and 'return' is hex
+// encoded 32 bytes.
+type Memo struct {
+	Type  string
+	Value string
+}
+
+// ParseMemo checks the memo value by type. The empty type is 'text' if the
+// value is given, if not, 'none'.
+func ParseMemo(memoType, value string) (memo Memo, err error) {
+	if len(memoType) < 1 {
+		memoType = MemoTypeText
+		if len(strings.TrimSpace(value)) < 1 {
+			memoType = MemoTypeNone
+		}
+	}
+
+	// only the text memo keeps the spaces
+	if memoType != MemoTypeText {
+		value = strings.TrimSpace(value)
+	}
+
+	switch memoType {
+	case MemoTypeNone:
+		if len(value) > 0 {
+			err = fmt.Errorf("memo type, 'none' can not have value, '%s'", value)
+			return
+		}
+	case MemoTypeText:
+		if len(value) > MaxMemoTextLength {
+			err = fmt.Errorf("too long text memo, '%s'; must not be longer than %d bytes", value, MaxMemoTextLength)
+			return
+		}
+	case MemoTypeID:
+		if _, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = fmt.Errorf("invalid id memo, '%s'; must be unsigned 64 bit integer", value)
+			return
+		}
+	case MemoTypeHash, MemoTypeReturn:
+		if h, e := hex.DecodeString(value); e != nil || len(h) != 32 {
+			err = fmt.Errorf("invalid %s memo, '%s'; must be hex encoded 32 bytes", memoType, value)
+			return
+		}
+	default:
+		err = fmt.Errorf("unknown memo type, '%s'", memoType)
+		return
+	}
+
+	return Memo{Type: memoType, Value: value}, nil
+}
+
+// MemoFromFlags makes the memo from the memo flags of commands; only one of
+// them can be given.
+func MemoFromFlags(text, id, hash, ret string) (memo Memo, err error) {
+	memo = Memo{Type: MemoTypeNone}
+
+	var n int
+	for _, m := range []Memo{{MemoTypeText, text}, {MemoTypeID, id}, {MemoTypeHash, hash}, {MemoTypeReturn, ret}} {
+		if len(m.Value) < 1 {
+			continue
+		}
+		n++
+
+		if memo, err = ParseMemo(m.Type, m.Value); err != nil {
+			return
+		}
+	}
+
+	if n > 1 {
+		err = fmt.Errorf("only one of -memo-text, -memo-id, -memo-hash and -memo-return can be given")
+	}
+
+	return
+}
+
+func (m Memo) IsEmpty() bool {
+	return len(m.Type) < 1 || m.Type == MemoTypeNone
+}
+
+func (m Memo) String() string {
+	if m.IsEmpty() {
+		return MemoTypeNone
+	}
+
+	return fmt.Sprintf("%s:%s", m.Type, m.Value)
+}
+
+// Mutator returns the memo for the transaction builder. The empty memo
+// returns nil.
+func (m Memo) Mutator() b.TransactionMutator {
+	switch m.Type {
+	case MemoTypeText:
+		return b.MemoText{m.Value}
+	case MemoTypeID:
+		id, _ := strconv.ParseUint(m.Value, 10, 64)
+		return b.MemoID{id}
+	case MemoTypeHash, MemoTypeReturn:
+		var h xdr.Hash
+		d, _ := hex.DecodeString(m.Value)
+		copy(h[:], d)
+
+		if m.Type == MemoTypeHash {
+			return b.MemoHash{h}
+		}
+		return b.MemoReturn{h}
+	}
+
+	return nil
+}
+
+// Mutators returns the memo as the list of mutators; it is empty with the
+// empty memo.
+func (m Memo) Mutators() []b.TransactionMutator {
+	if mut := m.Mutator(); mut != nil {
+		return []b.TransactionMutator{mut}
+	}
+
+	return nil
+}
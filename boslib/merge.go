@@ -0,0 +1,133 @@
+package boslib
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+)
+
+// MaxOperations is the maximum number of operations in one transaction.
+const MaxOperations = 100
+
+type MergeStep struct {
+	Description string
+	Operation   b.TransactionMutator
+}
+
+// MakeMergePlan makes the operations to merge account into the destination.
+// Before merging, the sub entries of account must be removed; the data
+// entries are removed, the offers are cancelled and the non-native balances
+// are returned to the issuers and then the trustlines are removed. At last
+// the additional signers are removed, so the transactions are signed by the
+// master key only and it's weight must meet the high threshold.
+func MakeMergePlan(account Account, offers []Offer, destination string) (steps []MergeStep, err error) {
+	var master uint32
+	var signers []AccountSigner
+	for _, s := range account.Signers {
+		key := s.Key
+		if len(key) < 1 {
+			key = s.PublicKey
+		}
+
+		if key == account.ID {
+			master = uint32(s.Weight)
+			continue
+		}
+		if len(s.Type) > 0 && s.Type != "ed25519_public_key" {
+			err = fmt.Errorf("signer, '%s' of type, '%s' can not be removed; remove it by stellar-set-options", key, s.Type)
+			return
+		}
+		signers = append(signers, AccountSigner{Key: key, Weight: s.Weight, Type: s.Type})
+	}
+	if high := uint32(account.Thresholds.HighThreshold); master < 1 || master < high {
+		err = fmt.Errorf(
+			"the weight of master key, %d does not meet the high threshold, %d; the master key can not remove the signers and merge the account",
+			master,
+			high,
+		)
+		return
+	}
+
+	var names []string
+	for name := range account.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		steps = append(steps, MergeStep{
+			Description: fmt.Sprintf("remove data entry, '%s'", name),
+			Operation:   b.ClearData(name),
+		})
+	}
+
+	for _, o := range offers {
+		var id uint64
+		if id, err = o.OfferID(); err != nil {
+			err = fmt.Errorf("invalid offer id, '%s': %v", o.ID, err)
+			return
+		}
+
+		// the decimal price of horizon is rounded, so the exact rational
+		// price is used
+		var op b.ManageOfferBuilder
+		if op, err = OfferOperation(o.SellingAsset(), o.BuyingAsset(), "0", o.PriceR, id, false); err != nil {
+			err = fmt.Errorf("failed to cancel offer, %d: %v", id, err)
+			return
+		}
+		steps = append(steps, MergeStep{
+			Description: fmt.Sprintf(
+				"cancel offer, %d: selling %s %s for %s",
+				id,
+				o.Amount,
+				AssetString(o.SellingAsset()),
+				AssetString(o.BuyingAsset()),
+			),
+			Operation: op,
+		})
+	}
+
+	for _, i := range account.Balances {
+		if i.AssetType == "native" {
+			continue
+		}
+
+		var balance xdr.Int64
+		if balance, err = amount.Parse(i.Balance); err != nil {
+			err = fmt.Errorf("invalid balance, '%s' of %s: %v", i.Balance, AssetString(i.Asset()), err)
+			return
+		}
+
+		if balance > 0 {
+			steps = append(steps, MergeStep{
+				Description: fmt.Sprintf("return %s %s to issuer", i.Balance, AssetString(i.Asset())),
+				Operation: b.Payment(
+					b.Destination{i.AssetIssuer},
+					b.CreditAmount{i.AssetCode, i.AssetIssuer, i.Balance},
+				),
+			})
+		}
+
+		steps = append(steps, MergeStep{
+			Description: fmt.Sprintf("remove trustline, %s", AssetString(i.Asset())),
+			Operation:   b.RemoveTrust(i.AssetCode, i.AssetIssuer),
+		})
+	}
+
+	for _, s := range signers {
+		steps = append(steps, MergeStep{
+			Description: fmt.Sprintf("remove signer, '%s' of weight, %d", s.Key, s.Weight),
+			Operation:   b.SetOptions(b.RemoveSigner(s.Key)),
+		})
+	}
+
+	steps = append(steps, MergeStep{
+		Description: fmt.Sprintf("merge account, '%s' into '%s'", account.ID, destination),
+		Operation:   b.AccountMerge(b.Destination{destination}),
+	})
+
+	return
+}
@@ -0,0 +1,207 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/price"
+	"github.com/stellar/go/xdr"
+)
+
+type OfferPrice struct {
+	N int32 `json:"n"`
+	D int32 `json:"d"`
+}
+
+type Offer struct {
+	ID      json.Number  `json:"id"`
+	Seller  string       `json:"seller"`
+	Selling horizonAsset `json:"selling"`
+	Buying  horizonAsset `json:"buying"`
+	Amount  string       `json:"amount"`
+	Price   string       `json:"price"`
+	PriceR  OfferPrice   `json:"price_r"`
+}
+
+func (o Offer) OfferID() (uint64, error) {
+	return strconv.ParseUint(o.ID.String(), 10, 64)
+}
+
+func (o Offer) SellingAsset() b.Asset {
+	return AssetFromHorizon(o.Selling.Type, o.Selling.Code, o.Selling.Issuer)
+}
+
+func (o Offer) BuyingAsset() b.Asset {
+	return AssetFromHorizon(o.Buying.Type, o.Buying.Code, o.Buying.Issuer)
+}
+
+// LoadAccountOffers loads all the open offers of account.
+func LoadAccountOffers(horizonUrl, address string) (offers []Offer, err error) {
+	p := NewPager("offers", CollectionURL(horizonUrl, PageQuery{Limit: MaxPageLimit}, "accounts", address, "offers"))
+	for p.Next() {
+		var o Offer
+		if err = p.Decode(&o); err != nil {
+			return
+		}
+		offers = append(offers, o)
+	}
+
+	err = p.Err()
+	return
+}
+
+// ParsePrice parses the price; decimal like '0.5' or exact rational like
+// '1/3'.
+func ParsePrice(s string) (p OfferPrice, err error) {
+	s = strings.TrimSpace(s)
+
+	a := strings.SplitN(s, "/", 2)
+	if len(a) == 2 {
+		var n, d int64
+		if n, err = strconv.ParseInt(strings.TrimSpace(a[0]), 10, 32); err != nil {
+			err = fmt.Errorf("invalid price, '%s': %v", s, err)
+			return
+		}
+		if d, err = strconv.ParseInt(strings.TrimSpace(a[1]), 10, 32); err != nil {
+			err = fmt.Errorf("invalid price, '%s': %v", s, err)
+			return
+		}
+		p = OfferPrice{N: int32(n), D: int32(d)}
+	} else {
+		var x xdr.Price
+		if x, err = price.Parse(s); err != nil {
+			err = fmt.Errorf("invalid price, '%s': %v", s, err)
+			return
+		}
+		p = OfferPrice{N: int32(x.N), D: int32(x.D)}
+	}
+
+	if p.N < 1 || p.D < 1 {
+		err = fmt.Errorf("invalid price, '%s'; must be positive", s)
+		return
+	}
+
+	return
+}
+
+func (p OfferPrice) Float() float64 {
+	return float64(p.N) / float64(p.D)
+}
+
+// Cmp compares the prices exactly; it returns -1, 0 or +1 like big.Rat.Cmp.
+func (p OfferPrice) Cmp(o OfferPrice) int {
+	a, c := int64(p.N)*int64(o.D), int64(o.N)*int64(p.D)
+	switch {
+	case a < c:
+		return -1
+	case a > c:
+		return 1
+	}
+
+	return 0
+}
+
+func (p OfferPrice) String() string {
+	return fmt.Sprintf("%d/%d", p.N, p.D)
+}
+
+// OfferOperation makes the 'manage_offer' operation; with zero offerID, new
+// offer is created, with zero amount, the offer is cancelled and with
+// passive, 'create_passive_offer' is made. The price is exactly set by the
+// rational price.
+func OfferOperation(selling, buying b.Asset, amount string, p OfferPrice, offerID uint64, passive bool) (
+	op b.ManageOfferBuilder,
+	err error,
+) {
+	// the builder takes only the decimal price, so '1' is given to build and
+	// the exact rational price is set to the operation after; the tiny price
+	// like 1/100000000 can not be written in decimal
+	rate := b.Rate{
+		Selling: selling,
+		Buying:  buying,
+		Price:   b.Price("1"),
+	}
+
+	switch {
+	case passive:
+		if offerID > 0 {
+			err = fmt.Errorf("passive offer can not be updated or cancelled by 'create_passive_offer'")
+			return
+		}
+		op = b.CreatePassiveOffer(rate, b.Amount(amount))
+	case offerID < 1:
+		op = b.CreateOffer(rate, b.Amount(amount))
+	case amount == "0":
+		op = b.DeleteOffer(rate, b.OfferID(offerID))
+	default:
+		op = b.UpdateOffer(rate, b.Amount(amount), b.OfferID(offerID))
+	}
+
+	if op.Err != nil {
+		err = op.Err
+		return
+	}
+
+	x := xdr.Price{N: xdr.Int32(p.N), D: xdr.Int32(p.D)}
+	if passive {
+		op.PP.Price = x
+	} else {
+		op.MO.Price = x
+	}
+
+	return
+}
+
+type OrderBookEntry struct {
+	Price  string     `json:"price"`
+	PriceR OfferPrice `json:"price_r"`
+	Amount string     `json:"amount"`
+}
+
+type OrderBook struct {
+	Bids []OrderBookEntry `json:"bids"`
+	Asks []OrderBookEntry `json:"asks"`
+}
+
+// LoadOrderBook loads the orderbook of the selling and buying asset pair.
+func LoadOrderBook(horizonUrl string, selling, buying b.Asset, limit int) (orderBook OrderBook, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "order_book")
+
+	q := url.Values{}
+	AssetQuery(q, "selling", selling)
+	AssetQuery(q, "buying", buying)
+	q.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get orderbook from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	if err = json.Unmarshal(body, &orderBook); err != nil {
+		err = fmt.Errorf("invalid orderbook received: %v", err)
+		return
+	}
+
+	return
+}
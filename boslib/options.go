@@ -0,0 +1,103 @@
+package boslib
+
+import (
+	"fmt"
+
+	b "github.com/stellar/go/build"
+)
+
+// OptionsChange is the set of account options to be changed. The nil field
+// will not be changed.
+type OptionsChange struct {
+	MasterWeight  *uint32
+	LowThreshold  *uint32
+	MedThreshold  *uint32
+	HighThreshold *uint32
+	Signers       []b.Signer // the signer of zero weight will be removed
+	SetFlags      []b.SetFlag
+	ClearFlags    []b.ClearFlag
+	HomeDomain    *string
+	InflationDest *string
+}
+
+func (c OptionsChange) IsEmpty() bool {
+	return len(c.Operations()) < 1
+}
+
+// Operations makes the 'set_options' operations. Only one signer can be
+// changed by one 'set_options', so each signer has it's own operation.
+func (c OptionsChange) Operations() (ops []b.TransactionMutator) {
+	var muts []interface{}
+	if c.MasterWeight != nil {
+		muts = append(muts, b.MasterWeight(*c.MasterWeight))
+	}
+	if c.LowThreshold != nil || c.MedThreshold != nil || c.HighThreshold != nil {
+		muts = append(muts, b.Thresholds{Low: c.LowThreshold, Medium: c.MedThreshold, High: c.HighThreshold})
+	}
+	for _, f := range c.SetFlags {
+		muts = append(muts, f)
+	}
+	for _, f := range c.ClearFlags {
+		muts = append(muts, f)
+	}
+	if c.HomeDomain != nil {
+		muts = append(muts, b.HomeDomain(*c.HomeDomain))
+	}
+	if c.InflationDest != nil {
+		muts = append(muts, b.InflationDest(*c.InflationDest))
+	}
+
+	if len(muts) > 0 {
+		ops = append(ops, b.SetOptions(muts...))
+	}
+
+	for _, s := range c.Signers {
+		ops = append(ops, b.SetOptions(s))
+	}
+
+	return
+}
+
+// CheckLockout checks whether the account still can meet it's own high
+// threshold after the change. If not, nobody can change the options of the
+// account anymore.
+func CheckLockout(account Account, c OptionsChange) error {
+	weights := map[string]uint32{}
+	for _, s := range account.Signers {
+		key := s.Key
+		if len(key) < 1 {
+			key = s.PublicKey
+		}
+		weights[key] = uint32(s.Weight)
+	}
+
+	if c.MasterWeight != nil {
+		weights[account.ID] = *c.MasterWeight
+	}
+	for _, s := range c.Signers {
+		weights[s.Address] = s.Weight
+	}
+
+	var total uint32
+	for _, w := range weights {
+		total += w
+	}
+
+	high := uint32(account.Thresholds.HighThreshold)
+	if c.HighThreshold != nil {
+		high = *c.HighThreshold
+	}
+
+	if total < 1 {
+		return fmt.Errorf("the total weight of signers will be 0; the account will be locked out")
+	}
+	if total < high {
+		return fmt.Errorf(
+			"the total weight of signers, %d will be lower than the high threshold, %d; the account will be locked out",
+			total,
+			high,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+// MaxPageLimit is the maximum number of records in one page of horizon.
+const MaxPageLimit = 200
+
+// PageQuery is the paging parameters of the collection endpoint of horizon;
+// the empty values are not sent.
+type PageQuery struct {
+	Cursor string
+	Order  string // 'asc' or 'desc'
+	Limit  int
+}
+
+// CollectionURL makes the url of the collection endpoint, like
+// '/accounts/<address>/payments' with the paging parameters.
+func CollectionURL(horizonUrl string, query PageQuery, elem ...string) string {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(append([]string{u.Path}, elem...)...)
+
+	values := url.Values{}
+	if len(query.Cursor) > 0 {
+		values.Set("cursor", query.Cursor)
+	}
+	if len(query.Order) > 0 {
+		values.Set("order", query.Order)
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	u.RawQuery = values.Encode()
+
+	return u.String()
+}
+
+// Pager iterates the records of the collection endpoint of horizon page by
+// page, following the 'next' link of page until the empty page.
+//
+//	p := NewPager("payments", CollectionURL(horizonUrl, PageQuery{Limit: 200}, "accounts", address, "payments"))
+//	for p.Next() {
+//		var r PaymentRecord
+//		if err := p.Decode(&r); err != nil {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Pager struct {
+	name    string // name of records for error message, like 'payments'
+	next    string
+	records []json.RawMessage
+	record  json.RawMessage
+	err     error
+}
+
+// NewPager makes the pager starting from the url of collection endpoint.
+func NewPager(name, u string) *Pager {
+	return &Pager{name: name, next: u}
+}
+
+// Next moves to the next record; if the page is exhausted, the next page is
+// loaded. It returns false at the end of records or on error.
+func (p *Pager) Next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	if len(p.records) < 1 {
+		if len(p.next) < 1 {
+			return false
+		}
+		if p.err = p.load(); p.err != nil {
+			return false
+		}
+		if len(p.records) < 1 {
+			return false
+		}
+	}
+
+	p.record, p.records = p.records[0], p.records[1:]
+
+	return true
+}
+
+func (p *Pager) load() error {
+	u := p.next
+	p.next = ""
+
+	response, err := http.Get(u)
+	if err != nil {
+		return fmt.Errorf("failed to connect to horizon, '%s': %v", u, err)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != 200 {
+		return fmt.Errorf("failed to get %s from horizon, '%s': %v", p.name, u, response.StatusCode)
+	}
+
+	var skel struct {
+		Links struct {
+			Next struct {
+				Href string `json:"href"`
+			} `json:"next"`
+		} `json:"_links"`
+		Embedded struct {
+			Records []json.RawMessage `json:"records"`
+		} `json:"_embedded"`
+	}
+	if err = json.Unmarshal(body, &skel); err != nil {
+		return fmt.Errorf("invalid %s received: %v", p.name, err)
+	}
+	log.Debugf("%d %s loaded from '%s'", len(skel.Embedded.Records), p.name, u)
+
+	p.records = skel.Embedded.Records
+	if len(p.records) > 0 {
+		p.next = skel.Links.Next.Href
+	}
+
+	return nil
+}
+
+// Record is the raw json of the current record.
+func (p *Pager) Record() json.RawMessage {
+	return p.record
+}
+
+// Decode decodes the current record.
+func (p *Pager) Decode(v interface{}) error {
+	if err := json.Unmarshal(p.record, v); err != nil {
+		return fmt.Errorf("invalid %s received: %v", p.name, err)
+	}
+
+	return nil
+}
+
+// Cursor is the paging token of the current record; the records after it
+// are loaded with this cursor.
+func (p *Pager) Cursor() string {
+	var skel struct {
+		PagingToken string `json:"paging_token"`
+	}
+	json.Unmarshal(p.record, &skel)
+
+	return skel.PagingToken
+}
+
+// Err is the error, which stopped the iteration.
+func (p *Pager) Err() error {
+	return p.err
+}
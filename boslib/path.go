@@ -0,0 +1,212 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+type PaymentPath struct {
+	SourceAsset       b.Asset
+	SourceAmount      string
+	DestinationAsset  b.Asset
+	DestinationAmount string
+	Path              []b.Asset
+}
+
+type horizonAsset struct {
+	Type   string `json:"asset_type"`
+	Code   string `json:"asset_code"`
+	Issuer string `json:"asset_issuer"`
+}
+
+type horizonPaymentPath struct {
+	SourceAssetType        string         `json:"source_asset_type"`
+	SourceAssetCode        string         `json:"source_asset_code"`
+	SourceAssetIssuer      string         `json:"source_asset_issuer"`
+	SourceAmount           string         `json:"source_amount"`
+	DestinationAssetType   string         `json:"destination_asset_type"`
+	DestinationAssetCode   string         `json:"destination_asset_code"`
+	DestinationAssetIssuer string         `json:"destination_asset_issuer"`
+	DestinationAmount      string         `json:"destination_amount"`
+	Path                   []horizonAsset `json:"path"`
+}
+
+// FindPaymentPaths queries the '/paths' of horizon and returns the paths,
+// which can be paid by the given source asset. The paths are sorted by the
+// source amount, so the first one is the cheapest.
+func FindPaymentPaths(
+	horizonUrl,
+	sourceAddress,
+	destinationAddress string,
+	sourceAsset,
+	destinationAsset b.Asset,
+	destinationAmount string,
+) (paths []PaymentPath, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "paths")
+
+	q := url.Values{}
+	q.Set("source_account", sourceAddress)
+	q.Set("destination_account", destinationAddress)
+	q.Set("destination_amount", destinationAmount)
+	AssetQuery(q, "destination", destinationAsset)
+	u.RawQuery = q.Encode()
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to find paths from horizon, '%s': %v: %s", u.String(), response.StatusCode, body)
+		return
+	}
+
+	var skel struct {
+		Embedded struct {
+			Records []horizonPaymentPath `json:"records"`
+		} `json:"_embedded"`
+	}
+	if err = json.Unmarshal(body, &skel); err != nil {
+		err = fmt.Errorf("invalid paths received: %v", err)
+		return
+	}
+
+	for _, r := range skel.Embedded.Records {
+		p := PaymentPath{
+			SourceAsset:       AssetFromHorizon(r.SourceAssetType, r.SourceAssetCode, r.SourceAssetIssuer),
+			SourceAmount:      r.SourceAmount,
+			DestinationAsset:  AssetFromHorizon(r.DestinationAssetType, r.DestinationAssetCode, r.DestinationAssetIssuer),
+			DestinationAmount: r.DestinationAmount,
+		}
+		if !AssetEqual(p.SourceAsset, sourceAsset) {
+			log.Debugf("path skipped; source asset, '%s' is not '%s'", AssetString(p.SourceAsset), AssetString(sourceAsset))
+			continue
+		}
+		if _, err = amount.Parse(p.SourceAmount); err != nil {
+			err = fmt.Errorf("invalid `source_amount` received: %v", r.SourceAmount)
+			return
+		}
+
+		for _, a := range r.Path {
+			p.Path = append(p.Path, AssetFromHorizon(a.Type, a.Code, a.Issuer))
+		}
+
+		paths = append(paths, p)
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return amount.MustParse(paths[i].SourceAmount) < amount.MustParse(paths[j].SourceAmount)
+	})
+
+	return
+}
+
+// AddSlippage adds the slippage tolerance in basis points to the source
+// amount of path; the added amount is rounded up to stroop.
+func AddSlippage(sourceAmount string, basisPoints int64) (maxSend string, err error) {
+	var a xdr.Int64
+	if a, err = amount.Parse(sourceAmount); err != nil {
+		return
+	}
+
+	extra := (a*xdr.Int64(basisPoints) + 9999) / 10000
+	maxSend = amount.String(a + extra)
+
+	return
+}
+
+// PathPaymentOperation makes the path payment operation; if maxSend is
+// empty, the source amount of path is used.
+func PathPaymentOperation(receiverAddress string, paymentPath PaymentPath, maxSend string) b.TransactionMutator {
+	if len(maxSend) < 1 {
+		maxSend = paymentPath.SourceAmount
+	}
+
+	payWith := b.PayWith(paymentPath.SourceAsset, maxSend)
+	for _, a := range paymentPath.Path {
+		payWith = payWith.Through(a)
+	}
+
+	return b.Payment(
+		b.Destination{receiverAddress},
+		AssetAmount(paymentPath.DestinationAsset, paymentPath.DestinationAmount),
+		payWith,
+	)
+}
+
+func SendPathPayment(
+	horizonUrl,
+	senderSeed,
+	receiverAddress,
+	networkPassphrase string,
+	paymentPath PaymentPath,
+	maxSend string,
+	seq xdr.SequenceNumber,
+	fee uint64,
+	muts ...b.TransactionMutator,
+) (
+	resp horizon.TransactionSuccess,
+	err error,
+) {
+	nc := MakeNetwork(horizonUrl)
+
+	var sp b.TransactionMutator
+	if seq < 1 {
+		sp = b.AutoSequence{nc}
+	} else {
+		sp = FixedSequence{seq}
+	}
+
+	muts = append(
+		[]b.TransactionMutator{
+			b.SourceAccount{senderSeed},
+			sp,
+			PathPaymentOperation(receiverAddress, paymentPath, maxSend),
+			b.BaseFee{Amount: fee},
+		},
+		muts...,
+	)
+
+	tx, err := b.Transaction(muts...)
+	if err != nil {
+		return
+	}
+
+	tx.NetworkPassphrase = networkPassphrase
+
+	txe, err := tx.Sign(senderSeed)
+	if err != nil {
+		return
+	}
+
+	var txeB64 string
+	if txeB64, err = txe.Base64(); err != nil {
+		err = &SigningError{err: err}
+		return
+	}
+
+	if resp, err = nc.SubmitTransaction(txeB64); err != nil {
+		return
+	}
+	log.Debugf("< transaction, 'path-payment' posted in ledger: %v", resp.Ledger)
+
+	return resp, nil
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/stellar/go/xdr"
 )
 
-func SendPayment(horizonUrl, senderSeed, receiverAddress, amount, networkPassphrase string, seq xdr.SequenceNumber, fee uint64) (
+// SendPayment sends the native payment. The extra mutators like memo are added
+// to the transaction.
+func SendPayment(horizonUrl, senderSeed, receiverAddress, amount, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, muts ...b.TransactionMutator) (
 	resp horizon.TransactionSuccess,
 	err error,
 ) {
@@ -19,15 +21,17 @@ func SendPayment(horizonUrl, senderSeed, receiverAddress, amount, networkPassphr
 		sp = FixedSequence{seq}
 	}
 
-	tx, err := b.Transaction(
-		b.SourceAccount{senderSeed},
-		sp,
-		b.Payment(
-			b.Destination{receiverAddress},
-			b.NativeAmount{amount},
-		),
-		b.BaseFee{Amount: fee},
+	muts = append(
+		[]b.TransactionMutator{
+			b.SourceAccount{senderSeed},
+			sp,
+			PaymentOperation(receiverAddress, amount),
+			b.BaseFee{Amount: fee},
+		},
+		muts...,
 	)
+
+	tx, err := b.Transaction(muts...)
 	if err != nil {
 		return
 	}
@@ -52,3 +56,75 @@ func SendPayment(horizonUrl, senderSeed, receiverAddress, amount, networkPassphr
 
 	return resp, nil
 }
+
+// PaymentOperation makes the native payment operation.
+func PaymentOperation(receiverAddress, amount string) b.TransactionMutator {
+	return b.Payment(
+		b.Destination{receiverAddress},
+		b.NativeAmount{amount},
+	)
+}
+
+// PaymentRow is the payment of the bulk payments.
+type PaymentRow struct {
+	Line    int
+	Address string
+	Amount  string
+	Asset   b.Asset
+	Memo    Memo
+}
+
+func (p PaymentRow) Operation() b.TransactionMutator {
+	return b.Payment(
+		b.Destination{p.Address},
+		AssetAmount(p.Asset, p.Amount),
+	)
+}
+
+// BatchPayments groups the payments by memo, because memo is for the whole
+// transaction, and then splits them by the maximum number of operations.
+func BatchPayments(rows []PaymentRow) (batches [][]PaymentRow) {
+	var memos []Memo
+	byMemo := map[Memo][]PaymentRow{}
+	for _, r := range rows {
+		if _, found := byMemo[r.Memo]; !found {
+			memos = append(memos, r.Memo)
+		}
+		byMemo[r.Memo] = append(byMemo[r.Memo], r)
+	}
+
+	for _, m := range memos {
+		rs := byMemo[m]
+		for i := 0; i < len(rs); i += MaxOperations {
+			end := i + MaxOperations
+			if end > len(rs) {
+				end = len(rs)
+			}
+			batches = append(batches, rs[i:end])
+		}
+	}
+
+	return
+}
+
+// SendPayments sends the payments in one transaction; the memo of the first
+// row is used. The extra mutators like time bounds are added to the
+// transaction.
+func SendPayments(horizonUrl, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, rows []PaymentRow, muts ...b.TransactionMutator) (
+	resp horizon.TransactionSuccess,
+	err error,
+) {
+	if len(rows) > 0 {
+		muts = append(muts, rows[0].Memo.Mutators()...)
+	}
+	for _, r := range rows {
+		muts = append(muts, r.Operation())
+	}
+
+	if resp, err = SubmitOperations(horizonUrl, senderSeed, networkPassphrase, seq, fee, muts...); err != nil {
+		return
+	}
+	log.Debugf("< transaction, %d 'payment' posted in ledger: %v", len(rows), resp.Ledger)
+
+	return
+}
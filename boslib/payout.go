@@ -0,0 +1,97 @@
+package boslib
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/stellar/go/xdr"
+)
+
+// FindInflationTransaction finds the hash of the inflation transaction in
+// the ledger.
+func FindInflationTransaction(horizonUrl string, ledger int32) (hash string, err error) {
+	p := NewPager(
+		"operations",
+		CollectionURL(horizonUrl, PageQuery{Limit: MaxPageLimit}, "ledgers", strconv.FormatInt(int64(ledger), 10), "operations"),
+	)
+	for p.Next() {
+		var r struct {
+			Type            string `json:"type"`
+			TransactionHash string `json:"transaction_hash"`
+		}
+		if err = p.Decode(&r); err != nil {
+			return
+		}
+
+		if r.Type == "inflation" {
+			return r.TransactionHash, nil
+		}
+	}
+	if err = p.Err(); err != nil {
+		return
+	}
+
+	err = fmt.Errorf("inflation is not found in ledger, %d", ledger)
+	return
+}
+
+type PayoutShare struct {
+	Address string
+	Balance xdr.Int64
+	Amount  xdr.Int64
+}
+
+// ComputePayoutShares divides the payout to the voters proportionally to
+// their balances after the pool fee, feeBasisPoints(1/100 of percent) is
+// taken. Each share is rounded down to stroop and the remaining stroops are
+// given one by one to the voters of the largest remainders; the ties are
+// broken by address, so the result is always same.
+func ComputePayoutShares(payout xdr.Int64, feeBasisPoints int64, voters []InflationVoter) (shares []PayoutShare, fee xdr.Int64) {
+	fee = xdr.Int64(new(big.Int).Div(
+		new(big.Int).Mul(big.NewInt(int64(payout)), big.NewInt(feeBasisPoints)),
+		big.NewInt(10000),
+	).Int64())
+	distributable := big.NewInt(int64(payout - fee))
+
+	total := new(big.Int)
+	for _, v := range voters {
+		total.Add(total, big.NewInt(int64(v.Balance)))
+	}
+	if total.Sign() < 1 {
+		fee = payout
+		return
+	}
+
+	remainders := make([]*big.Int, len(voters))
+	distributed := new(big.Int)
+	for i, v := range voters {
+		q, r := new(big.Int).QuoRem(
+			new(big.Int).Mul(distributable, big.NewInt(int64(v.Balance))),
+			total,
+			new(big.Int),
+		)
+		shares = append(shares, PayoutShare{Address: v.Address, Balance: v.Balance, Amount: xdr.Int64(q.Int64())})
+		remainders[i] = r
+		distributed.Add(distributed, q)
+	}
+
+	index := make([]int, len(shares))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		if c := remainders[index[i]].Cmp(remainders[index[j]]); c != 0 {
+			return c > 0
+		}
+		return shares[index[i]].Address < shares[index[j]].Address
+	})
+
+	left := new(big.Int).Sub(distributable, distributed).Int64()
+	for i := int64(0); i < left; i++ {
+		shares[index[i]].Amount++
+	}
+
+	return
+}
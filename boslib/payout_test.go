@@ -0,0 +1,126 @@
+package boslib
+
+import (
+	"testing"
+
+	"github.com/stellar/go/xdr"
+)
+
+func sumShares(shares []PayoutShare) (sum xdr.Int64) {
+	for _, s := range shares {
+		sum += s.Amount
+	}
+
+	return
+}
+
+func TestComputePayoutShares(t *testing.T) {
+	cases := []struct {
+		name           string
+		payout         xdr.Int64
+		feeBasisPoints int64
+		voters         []InflationVoter
+		fee            xdr.Int64
+		amounts        map[string]xdr.Int64
+	}{
+		{
+			name:    "even",
+			payout:  100,
+			voters:  []InflationVoter{{"A", 1}, {"B", 1}},
+			amounts: map[string]xdr.Int64{"A": 50, "B": 50},
+		},
+		{
+			name:    "largest remainder",
+			payout:  100,
+			voters:  []InflationVoter{{"A", 1}, {"B", 2}},
+			amounts: map[string]xdr.Int64{"A": 33, "B": 67},
+		},
+		{
+			name:    "tie by address",
+			payout:  10,
+			voters:  []InflationVoter{{"C", 1}, {"A", 1}, {"B", 1}},
+			amounts: map[string]xdr.Int64{"A": 4, "B": 3, "C": 3},
+		},
+		{
+			name:    "two stroops by remainders",
+			payout:  11,
+			voters:  []InflationVoter{{"C", 1}, {"B", 1}, {"A", 1}},
+			amounts: map[string]xdr.Int64{"A": 4, "B": 4, "C": 3},
+		},
+		{
+			name:           "pool fee",
+			payout:         1000,
+			feeBasisPoints: 150,
+			voters:         []InflationVoter{{"B", 7}, {"A", 3}},
+			fee:            15,
+			amounts:        map[string]xdr.Int64{"A": 296, "B": 689},
+		},
+		{
+			name:           "all to pool fee",
+			payout:         1000,
+			feeBasisPoints: 10000,
+			voters:         []InflationVoter{{"A", 1}},
+			fee:            1000,
+			amounts:        map[string]xdr.Int64{"A": 0},
+		},
+		{
+			name:   "no balances",
+			payout: 1000,
+			voters: []InflationVoter{{"A", 0}},
+			fee:    1000,
+		},
+	}
+
+	for _, c := range cases {
+		shares, fee := ComputePayoutShares(c.payout, c.feeBasisPoints, c.voters)
+		if fee != c.fee {
+			t.Errorf("%s: fee, %d; expected %d", c.name, fee, c.fee)
+		}
+		if len(shares) != len(c.amounts) {
+			t.Errorf("%s: %d shares; expected %d", c.name, len(shares), len(c.amounts))
+			continue
+		}
+		for _, s := range shares {
+			if s.Amount != c.amounts[s.Address] {
+				t.Errorf("%s: share of '%s', %d; expected %d", c.name, s.Address, s.Amount, c.amounts[s.Address])
+			}
+		}
+		if len(shares) > 0 && sumShares(shares) != c.payout-fee {
+			t.Errorf("%s: sum of shares, %d; expected %d", c.name, sumShares(shares), c.payout-fee)
+		}
+	}
+}
+
+func TestComputePayoutSharesSum(t *testing.T) {
+	var voters []InflationVoter
+	for i, a := range []string{"G1", "G2", "G3", "G4", "G5", "G6", "G7"} {
+		voters = append(voters, InflationVoter{a, xdr.Int64(1000000007 * (i*i + 1))})
+	}
+
+	for _, payout := range []xdr.Int64{1, 7, 999, 123456789, 9223372036} {
+		for _, bp := range []int64{0, 1, 150, 3333, 9999} {
+			shares, fee := ComputePayoutShares(payout, bp, voters)
+			if sum := sumShares(shares); sum != payout-fee {
+				t.Errorf("payout, %d, fee basis points, %d: sum of shares, %d; expected %d", payout, bp, sum, payout-fee)
+			}
+		}
+	}
+}
+
+func TestComputePayoutSharesOrder(t *testing.T) {
+	voters := []InflationVoter{{"A", 1}, {"B", 1}, {"C", 1}, {"D", 3}}
+	reversed := []InflationVoter{{"D", 3}, {"C", 1}, {"B", 1}, {"A", 1}}
+
+	a, _ := ComputePayoutShares(17, 0, voters)
+	b, _ := ComputePayoutShares(17, 0, reversed)
+
+	amounts := map[string]xdr.Int64{}
+	for _, s := range a {
+		amounts[s.Address] = s.Amount
+	}
+	for _, s := range b {
+		if amounts[s.Address] != s.Amount {
+			t.Errorf("share of '%s' depends on the order of voters; %d and %d", s.Address, amounts[s.Address], s.Amount)
+		}
+	}
+}
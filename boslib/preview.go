@@ -0,0 +1,199 @@
+package boslib
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// PreviewTransaction is the transaction built and signed by dry run.
+type PreviewTransaction struct {
+	Source     string
+	Envelope   string
+	Hash       string
+	Operations int
+	Fee        xdr.Int64
+}
+
+type balanceChange struct {
+	address string
+	asset   string
+	amount  xdr.Int64
+}
+
+// Preview is the result of dry run; the transactions are built and signed,
+// but not submitted. The changes of balances are given by the commands and
+// the fees are added by the transactions.
+type Preview struct {
+	Transactions []PreviewTransaction
+	horizonUrl   string
+	sequences    map[string]xdr.SequenceNumber
+	changes      []balanceChange
+}
+
+// NewPreview makes new preview; the balances and sequences are loaded from
+// horizon.
+func NewPreview(horizonUrl string) *Preview {
+	return &Preview{horizonUrl: horizonUrl, sequences: map[string]xdr.SequenceNumber{}}
+}
+
+// Build builds and signs new transaction. The transactions of preview are
+// not submitted, so the sequence of the source account is increased in the
+// preview.
+func (p *Preview) Build(channelSeed, senderSeed, networkPassphrase string, fee uint64, ops ...b.TransactionMutator) (
+	tx PreviewTransaction,
+	err error,
+) {
+	kp, err := keypair.Parse(channelSeed)
+	if err != nil {
+		return
+	}
+	tx.Source = kp.Address()
+
+	seq, found := p.sequences[tx.Source]
+	if !found {
+		if seq, err = LoadSequenceForAccount(p.horizonUrl, tx.Source); err != nil {
+			return
+		}
+	}
+
+	var builder *b.TransactionBuilder
+	builder, tx.Envelope, tx.Hash, err = buildChannelTransaction(p.horizonUrl, channelSeed, senderSeed, networkPassphrase, seq, fee, ops...)
+	if err != nil {
+		return
+	}
+	p.sequences[tx.Source] = seq + 1
+
+	tx.Operations = len(builder.TX.Operations)
+	tx.Fee = xdr.Int64(builder.TX.Fee)
+	p.Transactions = append(p.Transactions, tx)
+
+	p.changes = append(p.changes, balanceChange{address: tx.Source, asset: AssetString(b.NativeAsset()), amount: -tx.Fee})
+
+	return
+}
+
+// Debit records that the amount of asset will be sent from the account.
+func (p *Preview) Debit(address string, asset b.Asset, a string) error {
+	return p.change(address, asset, a, -1)
+}
+
+// Credit records that the amount of asset will be received by the account.
+func (p *Preview) Credit(address string, asset b.Asset, a string) error {
+	return p.change(address, asset, a, 1)
+}
+
+func (p *Preview) change(address string, asset b.Asset, a string, sign xdr.Int64) error {
+	v, err := amount.Parse(a)
+	if err != nil {
+		return fmt.Errorf("invalid amount, '%s': %v", a, err)
+	}
+
+	p.changes = append(p.changes, balanceChange{address: address, asset: AssetString(asset), amount: sign * v})
+
+	return nil
+}
+
+// TotalFee is the sum of the fees of all the transactions.
+func (p *Preview) TotalFee() (fee xdr.Int64) {
+	for _, t := range p.Transactions {
+		fee += t.Fee
+	}
+
+	return
+}
+
+// Print prints the transactions and the predicted balances of the affected
+// accounts. The current balances are loaded from horizon.
+func (p *Preview) Print(w io.Writer) error {
+	for i, t := range p.Transactions {
+		fmt.Fprintf(w, "transaction %d: %d operations, fee %s, source %s\n", i, t.Operations, amount.String(t.Fee), t.Source)
+		fmt.Fprintf(w, "      hash: %s\n", t.Hash)
+		fmt.Fprintf(w, "  envelope: %s\n", t.Envelope)
+	}
+	fmt.Fprintf(w, "total fee: %s\n", amount.String(p.TotalFee()))
+
+	var addresses []string
+	deltas := map[string]map[string]xdr.Int64{}
+	assets := map[string][]string{}
+	for _, c := range p.changes {
+		if _, found := deltas[c.address]; !found {
+			addresses = append(addresses, c.address)
+			deltas[c.address] = map[string]xdr.Int64{}
+		}
+		if _, found := deltas[c.address][c.asset]; !found {
+			assets[c.address] = append(assets[c.address], c.asset)
+		}
+		deltas[c.address][c.asset] += c.amount
+	}
+
+	fmt.Fprintln(w, "balances:")
+	for _, address := range addresses {
+		exists, err := CheckAddressExists(p.horizonUrl, address)
+		if err != nil {
+			return err
+		}
+
+		balances := map[string]string{}
+		if exists {
+			account, err := LoadAccount(p.horizonUrl, address)
+			if err != nil {
+				return err
+			}
+			for _, a := range account.Balances {
+				balances[AssetString(a.Asset())] = a.Balance
+			}
+		}
+
+		for _, asset := range assets[address] {
+			delta := deltas[address][asset]
+
+			before := "(new)"
+			var current xdr.Int64
+			if s, found := balances[asset]; found {
+				before = s
+				current, _ = amount.Parse(s)
+			}
+
+			var mark string
+			after := current + delta
+			if after < 0 {
+				mark = " (insufficient)"
+			}
+
+			sign := "+"
+			if delta < 0 {
+				sign = ""
+			}
+
+			fmt.Fprintf(
+				w,
+				"  %s %s: %s -> %s (%s%s)%s\n",
+				address,
+				asset,
+				before,
+				amount.String(after),
+				sign,
+				amount.String(delta),
+				mark,
+			)
+		}
+	}
+
+	return nil
+}
+
+// PreviewOperations builds and signs the transaction of operations like
+// SubmitOperations and prints it without submitting.
+func PreviewOperations(w io.Writer, horizonUrl, senderSeed, networkPassphrase string, fee uint64, ops ...b.TransactionMutator) error {
+	p := NewPreview(horizonUrl)
+	if _, err := p.Build(senderSeed, senderSeed, networkPassphrase, fee, ops...); err != nil {
+		return err
+	}
+
+	return p.Print(w)
+}
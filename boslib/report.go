@@ -0,0 +1,204 @@
+package boslib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	ReportSuccess  = "success"
+	ReportFailed   = "failed"
+	ReportRejected = "rejected"
+	ReportPending  = "pending"
+)
+
+// ReportRow is the result of one row of the bulk run. Fee is the fee charged
+// for the transaction, which includes the row.
+type ReportRow struct {
+	Line       int       `json:"line"`
+	Address    string    `json:"address"`
+	Amount     string    `json:"amount"`
+	Status     string    `json:"status"`
+	Batch      int       `json:"batch"`
+	Hash       string    `json:"hash,omitempty"`
+	Ledger     int32     `json:"ledger,omitempty"`
+	Fee        string    `json:"fee,omitempty"`
+	ResultCode string    `json:"result_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// ReportSummary is the totals of the bulk run. TotalFee includes the fees of
+// the failed transactions.
+type ReportSummary struct {
+	Rows         int    `json:"rows"`
+	Succeeded    int    `json:"succeeded"`
+	Failed       int    `json:"failed"`
+	Rejected     int    `json:"rejected"`
+	Pending      int    `json:"pending"`
+	Transactions int    `json:"transactions"`
+	TotalAmount  string `json:"total_amount"`
+	TotalFee     string `json:"total_fee"`
+}
+
+type Report struct {
+	Summary ReportSummary `json:"summary"`
+	Rows    []ReportRow   `json:"rows"`
+}
+
+// ReportRows returns the results of the rows of batch.
+func (e JournalEntry) ReportRows() (rows []ReportRow) {
+	rejects := map[int]JournalReject{}
+	for _, r := range e.Rejects {
+		rejects[r.Line] = r
+	}
+
+	var status string
+	switch e.Status {
+	case JournalSuccess:
+		status = ReportSuccess
+	case JournalFailed:
+		status = ReportFailed
+	default:
+		status = ReportPending
+	}
+
+	remaining := e.Remaining()
+
+	var i int
+	for _, b := range e.Rows {
+		row := ReportRow{
+			Line:    b.Line,
+			Address: b.Address,
+			Amount:  b.Amount,
+			Batch:   e.Batch,
+			Time:    e.Time,
+		}
+
+		if r, found := rejects[b.Line]; found {
+			row.Status = ReportRejected
+			row.ResultCode = r.Reason
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Status = status
+		row.Hash = e.Hash
+		row.Ledger = e.Ledger
+		row.Error = e.Error
+		if e.Fee > 0 {
+			row.Fee = amount.String(xdr.Int64(e.Fee))
+		}
+
+		if status == ReportSuccess {
+			row.ResultCode = "op_success"
+		} else if len(e.Codes) == len(remaining) {
+			row.ResultCode = e.Codes[i]
+		}
+		i++
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+// MakeReport makes the report from the entries of journal.
+func MakeReport(entries []JournalEntry) (report Report) {
+	var total, fee xdr.Int64
+	for _, e := range entries {
+		if e.Status == JournalSuccess {
+			report.Summary.Transactions++
+		}
+		fee += xdr.Int64(e.TotalFee)
+
+		for _, r := range e.ReportRows() {
+			switch r.Status {
+			case ReportSuccess:
+				report.Summary.Succeeded++
+				if a, err := amount.Parse(r.Amount); err == nil {
+					total += a
+				}
+			case ReportFailed:
+				report.Summary.Failed++
+			case ReportRejected:
+				report.Summary.Rejected++
+			default:
+				report.Summary.Pending++
+			}
+			report.Rows = append(report.Rows, r)
+		}
+	}
+
+	report.Summary.Rows = len(report.Rows)
+	report.Summary.TotalAmount = amount.String(total)
+	report.Summary.TotalFee = amount.String(fee)
+
+	return
+}
+
+// CheckReportFormat checks the file name of report; the format is decided by
+// the extension, '.json' or '.csv'.
+func CheckReportFormat(name string) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".csv":
+		return nil
+	default:
+		return fmt.Errorf("unknown report format, '%s'; must be '.json' or '.csv'", name)
+	}
+}
+
+// WriteReport writes the report to the file. The csv report has only the
+// rows.
+func WriteReport(name string, report Report) (err error) {
+	if err = CheckReportFormat(name); err != nil {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(name)) == ".json" {
+		e := json.NewEncoder(f)
+		e.SetIndent("", "  ")
+		return e.Encode(report)
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"line", "address", "amount", "status", "batch", "hash", "ledger", "fee", "result_code", "error", "time"})
+	for _, r := range report.Rows {
+		var ledger string
+		if r.Ledger > 0 {
+			ledger = strconv.FormatInt(int64(r.Ledger), 10)
+		}
+
+		w.Write([]string{
+			strconv.Itoa(r.Line),
+			r.Address,
+			r.Amount,
+			r.Status,
+			strconv.Itoa(r.Batch),
+			r.Hash,
+			ledger,
+			r.Fee,
+			r.ResultCode,
+			r.Error,
+			r.Time.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+
+	return w.Error()
+}
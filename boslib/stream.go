@@ -0,0 +1,161 @@
+package boslib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamHandler handles the event of stream; cursor is the paging token of
+// the record in data. If it returns error, the stream is stopped.
+type StreamHandler func(cursor string, data []byte) error
+
+// Stream reads the server-sent events of the collection endpoint of horizon,
+// like '/accounts/<address>/payments?cursor=now', until the connection is
+// closed. The last cursor, which was handled, is returned, so it can be
+// resumed from it.
+func Stream(u string, handler StreamHandler) (cursor string, err error) {
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set("Accept", "text/event-stream")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u, err)
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to stream from horizon, '%s': %v", u, response.StatusCode)
+		return
+	} else if t := response.Header.Get("Content-Type"); !strings.HasPrefix(t, "text/event-stream") {
+		err = fmt.Errorf("horizon, '%s' does not support streaming; 'Content-Type' is '%s'", u, t)
+		return
+	}
+	log.Debugf("stream connected: %s", u)
+
+	var id, event string
+	var data []string
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > 0 {
+			if strings.HasPrefix(line, ":") { // comment
+				continue
+			}
+
+			field, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+
+			switch field {
+			case "id":
+				id = value
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// the empty line dispatches the event; 'open' and 'close' events of
+		// horizon have no record
+		if len(data) > 0 && (len(event) < 1 || event == "message") {
+			b := []byte(strings.Join(data, "\n"))
+
+			c := id
+			if len(c) < 1 {
+				var skel struct {
+					PagingToken string `json:"paging_token"`
+				}
+				json.Unmarshal(b, &skel)
+				c = skel.PagingToken
+			}
+
+			if err = handler(c, b); err != nil {
+				return
+			}
+			cursor = c
+		}
+		id, event, data = "", "", nil
+	}
+
+	err = scanner.Err()
+	return
+}
+
+// nowCursor is the cursor of the events after the latest ledger, like the
+// 'now' cursor of horizon. The paging token of ledger is the id of the first
+// record in it, so the records of the next ledger are after the paging token
+// of the next ledger, but the ledgers are after the paging token of the
+// latest ledger itself.
+func nowCursor(horizonUrl string, elem ...string) (cursor string, err error) {
+	var ledger Ledger
+	if ledger, err = LoadLatestLedger(horizonUrl); err != nil {
+		return
+	}
+
+	sequence := int64(ledger.Sequence)
+	if len(elem) < 1 || elem[len(elem)-1] != "ledgers" {
+		sequence++
+	}
+
+	return strconv.FormatInt(sequence<<32, 10), nil
+}
+
+// Watch streams the events of the collection endpoint from the cursor and
+// connects again from the last cursor when the connection is closed, so
+// no event is missed. The 'now' cursor is resolved to the paging token of
+// the latest ledger before connecting, so the events while disconnected are
+// not missed even if no event was received before. It returns only when
+// handler returns error.
+func Watch(horizonUrl, cursor string, retry time.Duration, handler StreamHandler, elem ...string) error {
+	var handlerErr error
+	h := func(c string, data []byte) error {
+		handlerErr = handler(c, data)
+		return handlerErr
+	}
+
+	for cursor == "now" {
+		c, err := nowCursor(horizonUrl, elem...)
+		if err != nil {
+			log.Warnf("failed to load latest ledger for cursor, 'now', retry after %s: %v", retry, err)
+			time.Sleep(retry)
+			continue
+		}
+		log.Debugf("cursor, 'now' resolved to '%s'", c)
+		cursor = c
+	}
+
+	for {
+		c, err := Stream(CollectionURL(horizonUrl, PageQuery{Cursor: cursor}, elem...), h)
+		if handlerErr != nil {
+			return handlerErr
+		}
+		if len(c) > 0 {
+			cursor = c
+		}
+
+		switch {
+		case err != nil:
+			log.Warnf("stream disconnected, reconnect after %s: %v", retry, err)
+		case len(c) < 1:
+			log.Debugf("stream closed by horizon without events, reconnect after %s", retry)
+		default:
+			log.Debugf("stream closed by horizon, reconnect from cursor, '%s'", cursor)
+			continue
+		}
+		time.Sleep(retry)
+	}
+}
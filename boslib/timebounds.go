@@ -0,0 +1,170 @@
+package boslib
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	b "github.com/stellar/go/build"
+)
+
+// MaxClockSkew is the allowed difference between the local clock and the
+// close time of the latest ledger; the ledger is closed in every 5 seconds.
+const MaxClockSkew = time.Minute
+
+// TimeBounds is the time bounds of transaction. If ValidFor is set, the
+// maximum time is decided from the time when the transaction is built, so
+// the retried transaction of the long running command is not expired.
+type TimeBounds struct {
+	ValidFor time.Duration
+	MinTime  time.Time
+	MaxTime  time.Time
+}
+
+// ParseTime parses the time of RFC3339, like '2018-01-02T15:04:05Z', or
+// unix timestamp.
+func ParseTime(s string) (t time.Time, err error) {
+	if i, e := strconv.ParseInt(s, 10, 64); e == nil {
+		return time.Unix(i, 0), nil
+	}
+
+	if t, err = time.Parse(time.RFC3339, s); err != nil {
+		err = fmt.Errorf("invalid time, '%s'; must be RFC3339 or unix timestamp", s)
+	}
+
+	return
+}
+
+// MakeTimeBounds makes the time bounds from the flags of commands;
+// -valid-for and -max-time can not be given together.
+func MakeTimeBounds(validFor time.Duration, minTime, maxTime string) (tb TimeBounds, err error) {
+	if validFor < 0 {
+		err = fmt.Errorf("invalid -valid-for, %v; must be positive", validFor)
+		return
+	} else if validFor > 0 && len(maxTime) > 0 {
+		err = fmt.Errorf("-valid-for and -max-time can not be given together")
+		return
+	}
+	tb.ValidFor = validFor
+
+	if len(minTime) > 0 {
+		if tb.MinTime, err = ParseTime(minTime); err != nil {
+			err = fmt.Errorf("invalid -min-time: %v", err)
+			return
+		}
+	}
+
+	if len(maxTime) > 0 {
+		if tb.MaxTime, err = ParseTime(maxTime); err != nil {
+			err = fmt.Errorf("invalid -max-time: %v", err)
+			return
+		} else if !tb.MaxTime.After(time.Now()) {
+			err = fmt.Errorf("invalid -max-time, '%s'; already passed", tb.MaxTime.Format(time.RFC3339))
+			return
+		}
+	}
+
+	if !tb.MinTime.IsZero() && !tb.MaxTime.IsZero() && !tb.MaxTime.After(tb.MinTime) {
+		err = fmt.Errorf("-max-time must be later than -min-time")
+		return
+	}
+
+	return
+}
+
+// TimeBoundsFlags is the flags of time bounds for the commands making
+// transaction.
+type TimeBoundsFlags struct {
+	ValidFor time.Duration
+	MinTime  string
+	MaxTime  string
+}
+
+// SetTimeBoundsFlags adds -valid-for, -min-time and -max-time to the flag
+// set.
+func SetTimeBoundsFlags(flags *flag.FlagSet) *TimeBoundsFlags {
+	f := &TimeBoundsFlags{}
+	flags.DurationVar(&f.ValidFor, "valid-for", 0, "transaction is valid for the duration from when it is built, like '5m'")
+	flags.StringVar(&f.MinTime, "min-time", "", "transaction is not valid before the time, RFC3339 or unix timestamp")
+	flags.StringVar(&f.MaxTime, "max-time", "", "transaction is not valid after the time, RFC3339 or unix timestamp")
+
+	return f
+}
+
+// Resolve makes the time bounds from the flags. If the time bounds are
+// given, the local clock is checked with the latest ledger and the skew is
+// warned.
+func (f *TimeBoundsFlags) Resolve(horizonUrl string) (tb TimeBounds, err error) {
+	if tb, err = MakeTimeBounds(f.ValidFor, f.MinTime, f.MaxTime); err != nil {
+		return
+	}
+
+	if !tb.IsEmpty() {
+		if e := CheckClockSkew(horizonUrl); e != nil {
+			log.Warn(e)
+		}
+	}
+
+	return
+}
+
+func (t TimeBounds) IsEmpty() bool {
+	return t.ValidFor == 0 && t.MinTime.IsZero() && t.MaxTime.IsZero()
+}
+
+// Mutator returns the time bounds for the transaction builder. The empty
+// time bounds returns nil.
+func (t TimeBounds) Mutator() b.TransactionMutator {
+	if t.IsEmpty() {
+		return nil
+	}
+
+	var tb b.Timebounds
+	if !t.MinTime.IsZero() {
+		tb.MinTime = uint64(t.MinTime.Unix())
+	}
+
+	maxTime := t.MaxTime
+	if t.ValidFor > 0 {
+		maxTime = time.Now().Add(t.ValidFor)
+	}
+	if !maxTime.IsZero() {
+		tb.MaxTime = uint64(maxTime.Unix())
+	}
+
+	return tb
+}
+
+// Mutators returns the time bounds as the list of mutators; it is empty with
+// the empty time bounds.
+func (t TimeBounds) Mutators() []b.TransactionMutator {
+	if mut := t.Mutator(); mut != nil {
+		return []b.TransactionMutator{mut}
+	}
+
+	return nil
+}
+
+// CheckClockSkew compares the local clock with the close time of the latest
+// ledger. The time bounds are checked by the close time of ledger, so if the
+// local clock is wrong, the transaction can be expired already or not valid
+// yet.
+func CheckClockSkew(horizonUrl string) error {
+	ledger, err := LoadLatestLedger(horizonUrl)
+	if err != nil {
+		return err
+	}
+
+	skew := time.Now().Sub(ledger.ClosedAt)
+	if skew < -MaxClockSkew || skew > MaxClockSkew {
+		return fmt.Errorf(
+			"local clock is different with the latest ledger, %d closed at %s by %v; check the clock for the time bounds",
+			ledger.Sequence,
+			ledger.ClosedAt.Format(time.RFC3339),
+			skew,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package boslib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/clients/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+// BuildTransaction makes new transaction with the given operations and signs
+// it by the sender's secret seed. It returns the base64 encoded envelope and
+// the hash of transaction.
+func BuildTransaction(horizonUrl, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, ops ...b.TransactionMutator) (
+	txeB64 string,
+	hash string,
+	err error,
+) {
+	return BuildChannelTransaction(horizonUrl, senderSeed, senderSeed, networkPassphrase, seq, fee, ops...)
+}
+
+// BuildChannelTransaction makes new transaction, the source account of which
+// is the channel account; the sequence is from the channel account and the
+// transaction is signed by both the channel account and the sender. The
+// operations should have the sender as their source account.
+func BuildChannelTransaction(horizonUrl, channelSeed, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, ops ...b.TransactionMutator) (
+	txeB64 string,
+	hash string,
+	err error,
+) {
+	_, txeB64, hash, err = buildChannelTransaction(horizonUrl, channelSeed, senderSeed, networkPassphrase, seq, fee, ops...)
+	return
+}
+
+func buildChannelTransaction(horizonUrl, channelSeed, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, ops ...b.TransactionMutator) (
+	tx *b.TransactionBuilder,
+	txeB64 string,
+	hash string,
+	err error,
+) {
+	nc := MakeNetwork(horizonUrl)
+
+	var sp b.TransactionMutator
+	if seq < 1 {
+		sp = b.AutoSequence{nc}
+	} else {
+		sp = FixedSequence{seq}
+	}
+
+	muts := []b.TransactionMutator{
+		b.BaseFee{Amount: fee},
+		b.SourceAccount{channelSeed},
+		sp,
+	}
+	muts = append(muts, ops...)
+
+	if tx, err = b.Transaction(muts...); err != nil {
+		return
+	}
+
+	tx.NetworkPassphrase = networkPassphrase
+
+	if hash, err = tx.HashHex(); err != nil {
+		return
+	}
+
+	signers := []string{channelSeed}
+	if channelSeed != senderSeed {
+		signers = append(signers, senderSeed)
+	}
+
+	txe, err := tx.Sign(signers...)
+	if err != nil {
+		return
+	}
+
+	if txeB64, err = txe.Base64(); err != nil {
+		err = &SigningError{err: err}
+		return
+	}
+
+	return
+}
+
+// SubmitEnvelope submits the base64 encoded transaction envelope.
+func SubmitEnvelope(horizonUrl, txeB64 string) (resp horizon.TransactionSuccess, err error) {
+	nc := MakeNetwork(horizonUrl)
+
+	if resp, err = nc.SubmitTransaction(txeB64); err != nil {
+		return
+	}
+	log.Debugf("transaction, '%s' posted in ledger: %v", resp.Hash, resp.Ledger)
+
+	return
+}
+
+// SubmitOperations makes new transaction with the given operations, signs it
+// by the sender's secret seed and submits it.
+func SubmitOperations(horizonUrl, senderSeed, networkPassphrase string, seq xdr.SequenceNumber, fee uint64, ops ...b.TransactionMutator) (
+	resp horizon.TransactionSuccess,
+	err error,
+) {
+	txeB64, _, err := BuildTransaction(horizonUrl, senderSeed, networkPassphrase, seq, fee, ops...)
+	if err != nil {
+		return
+	}
+
+	return SubmitEnvelope(horizonUrl, txeB64)
+}
+
+type TransactionRecord struct {
+	Hash           string `json:"hash"`
+	Ledger         int32  `json:"ledger"`
+	FeePaid        int64  `json:"fee_paid"`
+	OperationCount int32  `json:"operation_count"`
+	CreatedAt      string `json:"created_at"`
+	Envelope       string `json:"envelope_xdr"`
+	Result         string `json:"result_xdr"`
+	Successful     *bool  `json:"successful"`
+}
+
+// Succeeded checks whether the transaction in ledger was applied
+// successfully; the failed transaction is also in ledger, because the fee
+// was charged. If horizon does not have 'successful', the result xdr is
+// checked.
+func (r TransactionRecord) Succeeded() bool {
+	if r.Successful != nil {
+		return *r.Successful
+	}
+
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(r.Result, &result); err != nil {
+		log.Errorf("invalid result xdr, '%s': %v", r.Result, err)
+		return false
+	}
+
+	return result.Result.Code == xdr.TransactionResultCodeTxSuccess
+}
+
+// LoadTransaction loads the transaction by hash. If the transaction is not
+// in ledger, found is false; the failed transaction is also found, see
+// TransactionRecord.Succeeded.
+func LoadTransaction(horizonUrl, hash string) (record TransactionRecord, found bool, err error) {
+	u, _ := url.Parse(horizonUrl)
+	u.Path = path.Join(u.Path, "transactions", hash)
+
+	response, err := http.Get(u.String())
+	if err != nil {
+		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode == 404 {
+		return
+	} else if response.StatusCode != 200 {
+		err = fmt.Errorf("failed to get transaction from horizon, '%s': %v", u.String(), response.StatusCode)
+		return
+	}
+
+	if err = json.Unmarshal(body, &record); err != nil {
+		err = fmt.Errorf("invalid transaction received: %v", err)
+		return
+	}
+
+	return record, true, nil
+}
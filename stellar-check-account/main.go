@@ -1,22 +1,25 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"text/template"
 
 	"github.com/spikeekips/stellar-utils/boslib"
 
 	"github.com/sirupsen/logrus"
-	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+	"gopkg.in/yaml.v2"
 )
 
 var log *logrus.Logger
@@ -24,8 +27,17 @@ var flags *flag.FlagSet
 
 var flagHorizon string
 var flagVerbose bool
+var flagOutput string
+var flagFormat string
+var flagConcurrency int
+var flagRate float64
+var flagFile string
+var flagAddressBook string
 
-var addresses []keypair.KP
+var addresses []string
+var book boslib.AddressBook
+var formatTemplate *template.Template
+var baseReserve xdr.Int64
 
 func usage(err error) {
 	if err != nil {
@@ -35,6 +47,66 @@ func usage(err error) {
 	os.Exit(1)
 }
 
+const (
+	statusOK           = "ok"
+	statusNotFound     = "not_found"
+	statusBelowReserve = "below_reserve" // lumens are less than the minimum balance
+	statusError        = "error"
+)
+
+type balanceView struct {
+	Asset     string `json:"asset" yaml:"asset"`
+	Balance   string `json:"balance" yaml:"balance"`
+	Limit     string `json:"limit,omitempty" yaml:"limit,omitempty"`
+	Available string `json:"available" yaml:"available"`
+	Reserved  string `json:"reserved,omitempty" yaml:"reserved,omitempty"`
+}
+
+type signerView struct {
+	Key    string `json:"key" yaml:"key"`
+	Type   string `json:"type" yaml:"type"`
+	Weight int32  `json:"weight" yaml:"weight"`
+}
+
+type thresholdsView struct {
+	Low    uint8 `json:"low" yaml:"low"`
+	Medium uint8 `json:"medium" yaml:"medium"`
+	High   uint8 `json:"high" yaml:"high"`
+}
+
+type flagsView struct {
+	AuthRequired  bool `json:"auth_required" yaml:"auth_required"`
+	AuthRevocable bool `json:"auth_revocable" yaml:"auth_revocable"`
+	AuthImmutable bool `json:"auth_immutable" yaml:"auth_immutable"`
+}
+
+// accountView is the account for display; the data entries are decoded and
+// the lumens are divided into the available and the reserved.
+type accountView struct {
+	Address              string            `json:"address" yaml:"address"`
+	Label                string            `json:"label,omitempty" yaml:"label,omitempty"`
+	Exists               bool              `json:"exists" yaml:"exists"`
+	Status               string            `json:"status" yaml:"status"`
+	Sequence             string            `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+	SubentryCount        int32             `json:"subentry_count" yaml:"subentry_count"`
+	HomeDomain           string            `json:"home_domain,omitempty" yaml:"home_domain,omitempty"`
+	InflationDestination string            `json:"inflation_destination,omitempty" yaml:"inflation_destination,omitempty"`
+	Balances             []balanceView     `json:"balances" yaml:"balances"`
+	Signers              []signerView      `json:"signers" yaml:"signers"`
+	Thresholds           thresholdsView    `json:"thresholds" yaml:"thresholds"`
+	Flags                flagsView         `json:"flags" yaml:"flags"`
+	Data                 map[string]string `json:"data" yaml:"data"`
+	Error                string            `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// assetTotal is the sum of the balances of asset in all the accounts.
+type assetTotal struct {
+	Asset     string `json:"asset" yaml:"asset"`
+	Balance   string `json:"balance" yaml:"balance"`
+	Available string `json:"available" yaml:"available"`
+	Accounts  int    `json:"accounts" yaml:"accounts"`
+}
+
 func init() {
 	log = logrus.New()
 	log.Level = logrus.InfoLevel
@@ -42,11 +114,18 @@ func init() {
 
 	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	flags.Usage = func() {
-		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address or label> [<public address or label>...]")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -file <addresses csv>")
 		flags.PrintDefaults()
 	}
 	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
 	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.StringVar(&flagOutput, "o", "", "output format, 'json', 'yaml', 'table' or 'csv'; default is the human readable view")
+	flags.StringVar(&flagFormat, "format", "", "go template for each account, like '{{ .Address }} {{ .Sequence }}'")
+	flags.IntVar(&flagConcurrency, "concurrency", 10, "number of accounts loaded at once")
+	flags.Float64Var(&flagRate, "rate", 0, "maximum requests to horizon per second; 0 is unlimited")
+	flags.StringVar(&flagFile, "file", "", "csv file of addresses with 'address' and 'label' columns")
+	flags.StringVar(&flagAddressBook, "address-book", "", "csv file of labels with 'address' and 'label' columns; the labels can be used instead of addresses")
 
 	flags.Parse(os.Args[1:])
 	if flagVerbose {
@@ -54,55 +133,397 @@ func init() {
 		boslib.SetLevel(log.Level)
 	}
 
-	if flags.NArg() < 1 {
-		usage(errors.New("<public address> is missing"))
+	switch flagOutput {
+	case "", "json", "yaml", "table", "csv":
+	default:
+		usage(fmt.Errorf("invalid -o, '%s'; must be 'json', 'yaml', 'table' or 'csv'", flagOutput))
 	}
 
+	if len(flagFormat) > 0 {
+		if len(flagOutput) > 0 {
+			usage(errors.New("-o and -format can not be given together"))
+		}
+
+		var err error
+		if formatTemplate, err = template.New("").Parse(flagFormat); err != nil {
+			usage(fmt.Errorf("invalid -format: %v", err))
+		}
+	}
+
+	if flagConcurrency < 1 {
+		usage(fmt.Errorf("invalid -concurrency, %d; must be greater than 0", flagConcurrency))
+	}
+	if flagRate < 0 {
+		usage(fmt.Errorf("invalid -rate, %v; must be 0 or positive", flagRate))
+	}
+
+	// address book
 	{
-		var invalidAddress []string
-		for _, a := range flags.Args() {
+		book = boslib.NewAddressBook()
+		if len(flagAddressBook) > 0 {
 			var err error
-			var address keypair.KP
-			if address, err = keypair.Parse(a); err != nil {
-				invalidAddress = append(invalidAddress, a)
-				continue
+			if book, err = boslib.ReadAddressBook(flagAddressBook); err != nil {
+				usage(fmt.Errorf("failed to read -address-book, '%s': %v", flagAddressBook, err))
+			}
+		}
+	}
+
+	// addresses; the duplicated addresses are checked once
+	{
+		var invalidAddress []string
+		added := map[string]bool{}
+		add := func(s string) string {
+			address, err := book.Resolve(s)
+			if err != nil {
+				invalidAddress = append(invalidAddress, s)
+				return ""
 			}
 
-			addresses = append(addresses, address)
+			if !added[address] {
+				added[address] = true
+				addresses = append(addresses, address)
+			}
+			return address
+		}
+
+		if len(flagFile) > 0 {
+			records, err := boslib.ReadCSV(flagFile, 1, "address", "label")
+			if err != nil {
+				usage(fmt.Errorf("failed to read -file, '%s': %v", flagFile, err))
+			}
+
+			for _, r := range records {
+				address := add(r.Address)
+				if len(address) < 1 || len(r.Label) < 1 {
+					continue
+				}
+				if err = book.Add(address, r.Label); err != nil {
+					usage(fmt.Errorf("failed to read -file, '%s': line %d: %v", flagFile, r.Line, err))
+				}
+			}
+		}
+
+		for _, a := range flags.Args() {
+			add(a)
 		}
 
 		if len(invalidAddress) > 0 {
-			usage(fmt.Errorf("found invalid public address or secret seed: %s", strings.Join(invalidAddress, ", ")))
+			usage(fmt.Errorf("found invalid public address, secret seed or label: %s", strings.Join(invalidAddress, ", ")))
+		}
+		if len(addresses) < 1 {
+			usage(errors.New("<public address> is missing"))
 		}
 	}
-}
 
-func main() {
-	for _, address := range addresses {
-		u, _ := url.Parse(flagHorizon)
-		u.Path = path.Join(u.Path, "accounts", address.Address())
-		response, err := http.Get(u.String())
+	// horizon
+	{
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if _, err := boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// base reserve
+	{
+		ledger, err := boslib.LoadLatestLedger(flagHorizon)
 		if err != nil {
-			usage(fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err))
+			usage(err)
+		}
+		baseReserve = xdr.Int64(ledger.BaseReserve)
+		log.Debugf("base reserve of ledger, %d: %s", ledger.Sequence, amount.String(baseReserve))
+	}
+}
+
+// makeAccountView makes the view of the loaded account; the lumens are
+// reserved by the base reserve of the latest ledger.
+func makeAccountView(r boslib.AccountResult) (v accountView) {
+	v.Address = r.Address
+	v.Label = book.Label(r.Address)
+	if r.Error != nil {
+		v.Status = statusError
+		if boslib.IsAccountNotFound(r.Error) {
+			v.Status = statusNotFound
+		}
+		v.Error = r.Error.Error()
+		return
+	}
+
+	a := r.Account
+	v.Exists = true
+	v.Status = statusOK
+	v.Sequence = a.Sequence
+	v.SubentryCount = a.SubentryCount
+	v.HomeDomain = a.HomeDomain
+	v.InflationDestination = a.InflationDestination
+	v.Thresholds = thresholdsView{
+		Low:    a.Thresholds.LowThreshold,
+		Medium: a.Thresholds.MedThreshold,
+		High:   a.Thresholds.HighThreshold,
+	}
+	v.Flags = flagsView{
+		AuthRequired:  a.Flags.AuthRequired,
+		AuthRevocable: a.Flags.AuthRevocable,
+		AuthImmutable: a.Flags.AuthImmutable,
+	}
+
+	for _, i := range a.Balances {
+		bv := balanceView{Asset: boslib.AssetString(i.Asset()), Balance: i.Balance, Available: i.Balance}
+		if i.AssetType == "native" {
+			reserved := a.MinimumBalance(baseReserve)
+			if balance, _ := amount.Parse(i.Balance); balance < reserved {
+				v.Status = statusBelowReserve
+			}
+			bv.Available = amount.String(a.Spendable(baseReserve))
+			bv.Reserved = amount.String(reserved)
+		} else {
+			bv.Limit = i.Limit
+		}
+		v.Balances = append(v.Balances, bv)
+	}
+
+	for _, s := range a.Signers {
+		key := s.Key
+		if len(key) < 1 {
+			key = s.PublicKey
 		}
+		v.Signers = append(v.Signers, signerView{Key: key, Type: s.Type, Weight: s.Weight})
+	}
 
-		if response.StatusCode == 404 {
-			log.Errorf("account, '%s' does not exist", address.Address())
+	v.Data = map[string]string{}
+	for name, value := range a.Data {
+		d, err := boslib.DecodeDataValue(value)
+		if err != nil {
+			log.Errorf("invalid value of data entry, '%s' of '%s' received: %v", name, a.ID, err)
 			continue
 		}
+		v.Data[name] = boslib.FormatDataValue(d)
+	}
+
+	return
+}
+
+// printView prints the account in the human readable form.
+func printView(v accountView) {
+	name := v.Address
+	if len(v.Label) > 0 {
+		name = fmt.Sprintf("%s (%s)", v.Address, v.Label)
+	}
+
+	if !v.Exists {
+		fmt.Printf("account: %s\n  (X) %s\n", name, v.Error)
+		return
+	}
+
+	fmt.Printf("account: %s\n", name)
+	if v.Status == statusBelowReserve {
+		fmt.Println("  (!) lumens are less than the minimum balance")
+	}
+	fmt.Printf("  sequence: %s\n", v.Sequence)
+	if len(v.HomeDomain) > 0 {
+		fmt.Printf("  home domain: %s\n", v.HomeDomain)
+	}
+	if len(v.InflationDestination) > 0 {
+		fmt.Printf("  inflation destination: %s\n", v.InflationDestination)
+	}
+	fmt.Printf("  subentries: %d\n", v.SubentryCount)
+
+	fmt.Println("  balances:")
+	for _, bv := range v.Balances {
+		if len(bv.Reserved) > 0 {
+			fmt.Printf("    %s: %s (available %s, reserved %s)\n", bv.Asset, bv.Balance, bv.Available, bv.Reserved)
+		} else {
+			fmt.Printf("    %s: %s (limit %s)\n", bv.Asset, bv.Balance, bv.Limit)
+		}
+	}
+
+	fmt.Println("  signers:")
+	for _, s := range v.Signers {
+		fmt.Printf("    %s: weight %d (%s)\n", s.Key, s.Weight, s.Type)
+	}
+
+	fmt.Printf("  thresholds: low %d, medium %d, high %d\n", v.Thresholds.Low, v.Thresholds.Medium, v.Thresholds.High)
+	fmt.Printf(
+		"  flags: auth_required %v, auth_revocable %v, auth_immutable %v\n",
+		v.Flags.AuthRequired,
+		v.Flags.AuthRevocable,
+		v.Flags.AuthImmutable,
+	)
+
+	if len(v.Data) > 0 {
+		var names []string
+		for name := range v.Data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
 
-		if response.StatusCode != 200 {
-			log.Errorf("failed to get response from horizon, '%s': %v", flagHorizon, response.StatusCode)
+		fmt.Println("  data:")
+		for _, name := range names {
+			fmt.Printf("    %s: %s\n", name, v.Data[name])
+		}
+	}
+}
+
+// computeTotals sums the balances of the existing accounts by asset; the
+// assets are in the order of appearance.
+func computeTotals(views []accountView) (totals []assetTotal) {
+	var assets []string
+	balances := map[string]xdr.Int64{}
+	available := map[string]xdr.Int64{}
+	accounts := map[string]int{}
+	for _, v := range views {
+		for _, bv := range v.Balances {
+			if _, found := accounts[bv.Asset]; !found {
+				assets = append(assets, bv.Asset)
+			}
+
+			b, _ := amount.Parse(bv.Balance)
+			a, _ := amount.Parse(bv.Available)
+			balances[bv.Asset] += b
+			available[bv.Asset] += a
+			accounts[bv.Asset]++
+		}
+	}
+
+	for _, asset := range assets {
+		totals = append(totals, assetTotal{
+			Asset:     asset,
+			Balance:   amount.String(balances[asset]),
+			Available: amount.String(available[asset]),
+			Accounts:  accounts[asset],
+		})
+	}
+
+	return
+}
+
+var tableColumns = []string{"label", "address", "asset", "balance", "available", "reserved", "subentries", "signers", "status"}
+
+// tableRows makes the rows of table, one row for each balance of account; the
+// account, which does not exist, has one row with status. With multiple
+// accounts, the totals by asset are added with 'TOTAL' label.
+func tableRows(views []accountView) (rows [][]string) {
+	for _, v := range views {
+		if !v.Exists {
+			rows = append(rows, []string{v.Label, v.Address, "", "", "", "", "", "", v.Status})
 			continue
 		}
 
-		body, err := ioutil.ReadAll(response.Body)
-		response.Body.Close()
+		for _, bv := range v.Balances {
+			rows = append(rows, []string{
+				v.Label,
+				v.Address,
+				bv.Asset,
+				bv.Balance,
+				bv.Available,
+				bv.Reserved,
+				strconv.Itoa(int(v.SubentryCount)),
+				strconv.Itoa(len(v.Signers)),
+				v.Status,
+			})
+		}
+	}
 
-		var skel map[string]interface{}
-		json.Unmarshal(body, &skel)
+	if len(views) < 2 {
+		return
+	}
+
+	for _, t := range computeTotals(views) {
+		rows = append(rows, []string{"TOTAL", "", t.Asset, t.Balance, t.Available, "", "", "", fmt.Sprintf("%d accounts", t.Accounts)})
+	}
+
+	return
+}
+
+// printTable prints the balances of all the accounts in one table.
+func printTable(views []accountView) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(tableColumns, "\t")))
+	for _, row := range tableRows(views) {
+		for i := range row {
+			if len(row[i]) < 1 {
+				row[i] = "-"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// printCSV prints the same rows with printTable in csv.
+func printCSV(views []accountView) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write(tableColumns)
+	for _, row := range tableRows(views) {
+		w.Write(row)
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+func main() {
+	var views []accountView
+	var failed int
+	for _, r := range boslib.LoadAccounts(flagHorizon, addresses, flagConcurrency, flagRate) {
+		if r.Error != nil {
+			failed++
+			if !boslib.IsAccountNotFound(r.Error) {
+				log.Error(r.Error)
+			}
+		}
+		views = append(views, makeAccountView(r))
+	}
+
+	// the output has same shape regardless of the number of accounts
+	output := struct {
+		Accounts []accountView `json:"accounts" yaml:"accounts"`
+		Totals   []assetTotal  `json:"totals" yaml:"totals"`
+	}{views, computeTotals(views)}
 
-		s, _ := json.MarshalIndent(skel, "", "  ")
+	switch {
+	case formatTemplate != nil:
+		for _, v := range views {
+			if err := formatTemplate.Execute(os.Stdout, v); err != nil {
+				log.Errorf("failed to execute -format: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println()
+		}
+	case flagOutput == "json":
+		s, _ := json.MarshalIndent(output, "", "  ")
 		fmt.Println(string(s))
+	case flagOutput == "yaml":
+		s, err := yaml.Marshal(output)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		fmt.Print(string(s))
+	case flagOutput == "table":
+		printTable(views)
+	case flagOutput == "csv":
+		if err := printCSV(views); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	default:
+		for i, v := range views {
+			if i > 0 {
+				fmt.Println()
+			}
+			printView(v)
+		}
+
+		if len(views) > 1 {
+			fmt.Println()
+			fmt.Println("total:")
+			for _, t := range computeTotals(views) {
+				fmt.Printf("  %s: %s (available %s) in %d accounts\n", t.Asset, t.Balance, t.Available, t.Accounts)
+			}
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
 	}
 }
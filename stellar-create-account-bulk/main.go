@@ -5,18 +5,19 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"html/template"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/spikeekips/stellar-utils/boslib"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
 
 	"github.com/sirupsen/logrus"
 )
@@ -30,6 +31,20 @@ var flagVerbose bool
 var flagSecretSeed string
 var flagCSVFileName string
 var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagJournal string
+var flagChannels string
+var flagBatchSize int
+var flagConcurrency int
+var flagCheckConcurrency int
+var flagRejects string
+var flagReport string
+var flagMemoText string
+var flagMemoID string
+var flagMemoHash string
+var flagMemoReturn string
 
 func usage(err error) {
 	if err != nil {
@@ -39,22 +54,13 @@ func usage(err error) {
 	os.Exit(1)
 }
 
-var balanceData [][]boslib.Balance
-
-func checkAddressExists(horizonUrl, address string) (exists bool, err error) {
-	exists = false
+var timeBounds boslib.TimeBounds
+var feeAuto bool
 
-	// check whether account exists or not
-	u, _ := url.Parse(horizonUrl)
-	u.Path = path.Join(u.Path, "accounts", address)
-	response, err := http.Get(u.String())
-	if err != nil {
-		err = fmt.Errorf("failed to connect to horizon, '%s': %v", u.String(), err)
-		return
-	}
-
-	return response.StatusCode == 200, nil
-}
+var balanceData [][]boslib.Balance
+var journal *boslib.Journal
+var secretSeedKP keypair.KP
+var channelSeeds []string
 
 func init() {
 	log = logrus.New()
@@ -68,7 +74,20 @@ func init() {
 	}
 	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
 	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
-	flags.Uint64Var(&flagFee, "fee", boslib.DefaultFee, "transaction fee")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.StringVar(&flagJournal, "journal", "", "journal file; default is '<csv>.journal'")
+	flags.StringVar(&flagChannels, "channels", "", "csv file of the secret seeds of channel accounts")
+	flags.IntVar(&flagBatchSize, "batch-size", boslib.MaxOperations, "number of operations in one transaction")
+	flags.IntVar(&flagCheckConcurrency, "check-concurrency", 10, "number of accounts checked at once in validation")
+	flags.IntVar(&flagConcurrency, "concurrency", 0, "number of transactions submitted at once; default is the number of channels")
+	flags.StringVar(&flagMemoText, "memo-text", "", "text memo for the rows without memo, up to 28 bytes")
+	flags.StringVar(&flagMemoID, "memo-id", "", "id memo for the rows without memo, unsigned 64 bit integer")
+	flags.StringVar(&flagMemoHash, "memo-hash", "", "hash memo for the rows without memo, hex encoded 32 bytes")
+	flags.StringVar(&flagMemoReturn, "memo-return", "", "return memo for the rows without memo, hex encoded 32 bytes")
+	flags.StringVar(&flagReport, "report", "", "report file of the results, '.json' or '.csv'")
+	flags.StringVar(&flagRejects, "rejects", "", "csv file of the rows removed from the failed batches; default is '<csv>.rejects.csv'")
 
 	flags.Parse(os.Args[1:])
 	if flagVerbose {
@@ -82,8 +101,18 @@ func init() {
 
 	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
 
+	flagReport = strings.TrimSpace(flagReport)
+	if len(flagReport) > 0 {
+		if err := boslib.CheckReportFormat(flagReport); err != nil {
+			usage(fmt.Errorf("invalid -report: %v", err))
+		}
+	}
+
+	if flagBatchSize < 1 || flagBatchSize > boslib.MaxOperations {
+		usage(fmt.Errorf("invalid -batch-size, %d; must be 1 to %d", flagBatchSize, boslib.MaxOperations))
+	}
+
 	// secret seed
-	var secretSeedKP keypair.KP
 	{
 		var err error
 		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
@@ -92,7 +121,7 @@ func init() {
 			usage(fmt.Errorf("not <secret seed>, this is public address"))
 		}
 
-		if exists, err := checkAddressExists(flagHorizon, secretSeedKP.Address()); err != nil {
+		if exists, err := boslib.CheckAddressExists(flagHorizon, secretSeedKP.Address()); err != nil {
 			usage(err)
 		} else if !exists {
 			usage(fmt.Errorf(
@@ -143,63 +172,635 @@ func init() {
 		}
 	}
 
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// memo; the memo of csv row overrides it
+	var memo boslib.Memo
+	{
+		var err error
+		if memo, err = boslib.MemoFromFlags(flagMemoText, flagMemoID, flagMemoHash, flagMemoReturn); err != nil {
+			usage(err)
+		}
+	}
+
+	// csv: <public address>,<amount>[,<memo>[,<memo type>]]; the rows with
+	// different memo are submitted in different transactions.
 	flagCSVFileName = strings.TrimSpace(flags.Arg(1))
 	{
+		records, err := boslib.ReadCSV(flagCSVFileName, 2, "address", "amount", "memo", "memo_type")
+		if err != nil {
+			usage(fmt.Errorf("failed to read csv file, '%s': %v", flagCSVFileName, err))
+		}
+
+		// the rows are validated later
+		var rows []boslib.Balance
+		for _, r := range records {
+			m := memo
+			if len(r.Memo) > 0 || len(r.MemoType) > 0 {
+				if m, err = boslib.ParseMemo(r.MemoType, r.Memo); err != nil {
+					usage(fmt.Errorf("line %d: invalid <memo>: %v", r.Line, err))
+				}
+			}
+
+			row := boslib.Balance{Line: r.Line, Amount: r.Amount, Address: r.Address}
+			if !m.IsEmpty() {
+				row.MemoType, row.Memo = m.Type, m.Value
+			}
+			rows = append(rows, row)
+		}
+
+		balanceData = boslib.BatchBalances(rows, flagBatchSize)
+	}
+
+	// channels
+	if len(flagChannels) > 0 {
 		var err error
 		var f *os.File
 
-		if f, err = os.Open(flagCSVFileName); err != nil {
+		if f, err = os.Open(flagChannels); err != nil {
 			usage(err)
 		}
 
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+
 		var csvContent [][]string
-		if csvContent, err = csv.NewReader(f).ReadAll(); err != nil {
+		if csvContent, err = r.ReadAll(); err != nil {
 			usage(err)
 		}
+		f.Close()
 
-		var bs []boslib.Balance
-		for n, b := range csvContent {
-			address := b[0]
-			if _, err = strconv.ParseFloat(b[1], 64); err != nil {
+		for n, l := range csvContent {
+			seed := strings.TrimSpace(l[0])
+			if len(seed) < 1 {
+				continue
+			}
+
+			var kp keypair.KP
+			if kp, err = keypair.Parse(seed); err != nil {
+				usage(fmt.Errorf("invalid channel secret seed at line, %d: %v", n+1, err))
+			} else if string([]rune(seed)[0]) != "S" {
+				usage(fmt.Errorf("not channel secret seed at line, %d, this is public address", n+1))
+			} else if kp.Address() == secretSeedKP.Address() {
+				usage(fmt.Errorf("channel at line, %d is same with <secret seed>", n+1))
+			}
+
+			if exists, err := boslib.CheckAddressExists(flagHorizon, kp.Address()); err != nil {
 				usage(err)
+			} else if !exists {
+				usage(fmt.Errorf("channel account, '%s' at line, %d was not found in network", kp.Address(), n+1))
 			}
 
-			balance := b[1]
+			channelSeeds = append(channelSeeds, seed)
+		}
+	}
+
+	// concurrency; one channel account can not submit transactions at once
+	{
+		maxConcurrency := len(channelSeeds)
+		if maxConcurrency < 1 {
+			maxConcurrency = 1
+		}
 
-			if n != 0 && n%100 == 0 {
-				balanceData = append(balanceData, bs)
-				bs = []boslib.Balance{}
+		if flagConcurrency < 1 {
+			flagConcurrency = maxConcurrency
+		} else if flagConcurrency > maxConcurrency {
+			usage(fmt.Errorf("invalid -concurrency, %d; must not be greater than the number of channels, %d", flagConcurrency, maxConcurrency))
+		}
+	}
+
+	// journal; with -dry-run, the journal is not created
+	{
+		var err error
+		if len(flagJournal) < 1 {
+			flagJournal = flagCSVFileName + ".journal"
+		}
+
+		openJournal := boslib.OpenJournal
+		if flagDryRun {
+			openJournal = boslib.ReadJournal
+		}
+		if journal, err = openJournal(flagJournal); err != nil {
+			usage(fmt.Errorf("failed to open journal, '%s': %v", flagJournal, err))
+		}
+
+		if len(flagRejects) < 1 {
+			flagRejects = flagCSVFileName + ".rejects.csv"
+		}
+	}
+}
+
+// sameRows checks whether the rows of batch in journal are same with the
+// csv.
+func sameRows(a, c []boslib.Balance) bool {
+	if len(a) != len(c) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != c[i].Address || a[i].Amount != c[i].Amount {
+			return false
+		}
+		if a[i].MemoType != c[i].MemoType || a[i].Memo != c[i].Memo {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validate checks all the rows before any transaction is submitted and
+// returns all the errors found. The rows of batches, which were already
+// submitted by journal, are not checked for existence and balance and the
+// rejected rows are not checked.
+func validate() (errs []string) {
+	// the minimum balance of new account and the base fee are of the latest
+	// ledger
+	ledger, err := boslib.LoadLatestLedger(flagHorizon)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to load latest ledger: %v", err))
+		return
+	}
+	if err = ledger.CheckFee(flagFee); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid -fee: %v", err))
+	}
+	minimum := ledger.MinimumBalance(0)
+
+	seen := map[string]int{}
+	var lines []int
+	var addresses []string
+	var total xdr.Int64
+	var batches, maxRows int // the batches to be submitted and the largest of them
+	for n, rows := range balanceData {
+		pending := len(addresses)
+
+		e, found := journal.Entry(n)
+		submitted := found && (e.Status == boslib.JournalSuccess || e.Status == boslib.JournalSubmitting)
+
+		rejected := map[int]bool{}
+		for _, r := range e.Rejects {
+			rejected[r.Line] = true
+		}
+
+		for _, r := range rows {
+			if rejected[r.Line] {
+				continue
+			}
+			if _, err := keypair.Parse(r.Address); err != nil {
+				errs = append(errs, fmt.Sprintf("line %d: invalid <public address>, '%s': %v", r.Line, r.Address, err))
+				continue
+			}
+			if r.Address == secretSeedKP.Address() {
+				errs = append(errs, fmt.Sprintf("line %d: <public address>, '%s' is the funding account", r.Line, r.Address))
+			}
+			if l, found := seen[r.Address]; found {
+				errs = append(errs, fmt.Sprintf("line %d: <public address>, '%s' is duplicated with line %d", r.Line, r.Address, l))
+			} else {
+				seen[r.Address] = r.Line
 			}
 
-			bs = append(bs, boslib.Balance{Amount: balance, Address: address})
+			a, err := amount.Parse(r.Amount)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("line %d: invalid <amount>, '%s': %v", r.Line, r.Amount, err))
+				continue
+			} else if a < minimum {
+				errs = append(errs, fmt.Sprintf(
+					"line %d: <amount>, '%s' is lower than the minimum balance, %s",
+					r.Line,
+					r.Amount,
+					amount.String(minimum),
+				))
+			}
+
+			if submitted {
+				continue
+			}
+			total += a
+			lines = append(lines, r.Line)
+			addresses = append(addresses, r.Address)
 		}
 
-		if len(bs) > 0 {
-			balanceData = append(balanceData, bs)
+		if pending = len(addresses) - pending; pending > 0 {
+			batches++
+			if pending > maxRows {
+				maxRows = pending
+			}
 		}
 	}
+
+	exists, err := boslib.CheckAddressesExist(flagHorizon, addresses, flagCheckConcurrency)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to check accounts: %v", err))
+	}
+	for i, a := range addresses {
+		if exists[a] {
+			errs = append(errs, fmt.Sprintf("line %d: account, '%s' is already registered in horizon", lines[i], a))
+		}
+	}
+
+	// the funding account must afford the amounts, fees and it's own minimum
+	// balance by it's subentries. With channels, the fees are paid by the
+	// channel accounts, the sources of transactions.
+	account, err := boslib.LoadAccount(flagHorizon, secretSeedKP.Address())
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to load funding account: %v", err))
+		return
+	}
+
+	baseReserve := xdr.Int64(ledger.BaseReserve)
+	var fees xdr.Int64
+	if len(channelSeeds) < 1 {
+		fees = xdr.Int64(flagFee) * xdr.Int64(len(addresses))
+	}
+	if required := total + fees; account.Spendable(baseReserve) < required {
+		errs = append(errs, fmt.Sprintf(
+			"insufficient balance of funding account, %s; %s is required, amounts: %s + fees: %s, but only %s is spendable over the minimum balance: %s",
+			account.NativeBalance(),
+			amount.String(required),
+			amount.String(total),
+			amount.String(fees),
+			amount.String(account.Spendable(baseReserve)),
+			amount.String(account.MinimumBalance(baseReserve)),
+		))
+	}
+
+	errs = append(errs, validateChannels(ledger, batches, maxRows)...)
+
+	return
 }
 
-func main() {
-	for _, b := range balanceData {
-		_, err := boslib.CreateAccounts(
+// validateChannels checks the channel accounts can afford the fees of their
+// share of batches; the batches are taken by the channels in turn, so each
+// channel is assumed to submit the even share of the batches of the largest
+// size.
+func validateChannels(ledger boslib.Ledger, batches, maxRows int) (errs []string) {
+	if len(channelSeeds) < 1 || batches < 1 {
+		return
+	}
+
+	channels := channelSeeds[:flagConcurrency]
+	share := (batches + len(channels) - 1) / len(channels)
+	fees := xdr.Int64(flagFee) * xdr.Int64(share*maxRows)
+
+	for _, seed := range channels {
+		kp, err := keypair.Parse(seed)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid channel secret seed: %v", err))
+			continue
+		}
+		address := kp.Address()
+
+		account, err := boslib.LoadAccount(flagHorizon, address)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to load channel account, '%s': %v", address, err))
+			continue
+		}
+		if err := ledger.CheckSpendable(account, fees); err != nil {
+			errs = append(errs, fmt.Sprintf("channel account can not afford the fees of %d batches: %v", share, err))
+		}
+	}
+
+	return
+}
+
+// isolatedCodes are the operation result codes, which are caused by the row
+// itself; the other codes like 'op_underfunded' are caused by the funding
+// account, so the rows are not removed.
+var isolatedCodes = map[string]bool{
+	"op_malformed":      true,
+	"op_already_exists": true,
+	"op_low_reserve":    true,
+}
+
+// batchOperations makes the operations of batch with the memo and time
+// bounds; the rows of batch have same memo.
+func batchOperations(source string, rows []boslib.Balance) []b.TransactionMutator {
+	memo, _ := boslib.ParseMemo(rows[0].MemoType, rows[0].Memo)
+
+	muts := append(memo.Mutators(), timeBounds.Mutators()...)
+
+	return append(muts, boslib.CreateAccountOperations(source, rows...)...)
+}
+
+// submitBatch submits the batch and if some operations failed, the rows of
+// them are removed from the batch and the remaining rows are submitted again.
+func submitBatch(channelSeed, source string, n int) (err error) {
+	for {
+		e, _ := journal.Entry(n)
+		rows := e.Remaining()
+		if len(rows) < 1 {
+			return nil
+		}
+
+		_, err = boslib.SubmitChannelBatch(
 			flagHorizon,
+			channelSeed,
 			flagSecretSeed,
 			flagNetworkPassphrase,
-			0,
 			flagFee,
-			b...,
+			journal,
+			n,
+			batchOperations(source, rows)...,
 		)
+		if err == nil {
+			return
+		}
 
-		for _, i := range b {
-			t := template.Must(template.New("").Parse("({{ if .err }}X{{ else }}O{{ end }}) {{ .b.Address }} : {{ .b.Amount }}\n"))
-			t.Execute(os.Stdout, map[string]interface{}{
-				"b":   i,
-				"err": err != nil,
-			})
+		codes, found := boslib.ResultCodes(err)
+		if !found || codes.TransactionCode != "tx_failed" || len(codes.OperationCodes) != len(rows) {
+			return
 		}
-		if err != nil {
-			log.Error(err)
+
+		var rejects []boslib.JournalReject
+		for i, c := range codes.OperationCodes {
+			if c == "op_success" {
+				continue
+			} else if !isolatedCodes[c] {
+				return
+			}
+			rejects = append(rejects, boslib.JournalReject{Balance: rows[i], Reason: c})
+		}
+		if len(rejects) < 1 {
+			return
+		}
+
+		for _, r := range rejects {
+			log.Debugf("line %d: '%s' was removed from batch, %d: %s", r.Line, r.Address, n, r.Reason)
+		}
+		if err = journal.Reject(n, rejects...); err != nil {
+			return
+		}
+	}
+}
+
+// dryRun builds and signs the transactions of the batches, which are not
+// confirmed yet, and prints them without submitting; the journal is not
+// changed.
+func dryRun() {
+	var source string
+	if len(channelSeeds) > 0 {
+		source = secretSeedKP.Address()
+	}
+
+	p := boslib.NewPreview(flagHorizon)
+	for n, rows := range balanceData {
+		if e, found := journal.Entry(n); found {
+			if e.Status == boslib.JournalSuccess {
+				continue
+			}
+			rows = e.Remaining()
+		}
+		if len(rows) < 1 {
+			continue
+		}
+
+		channelSeed := flagSecretSeed
+		if len(channelSeeds) > 0 {
+			channelSeed = channelSeeds[n%len(channelSeeds)]
+		}
+
+		if _, err := p.Build(channelSeed, flagSecretSeed, flagNetworkPassphrase, flagFee, batchOperations(source, rows)...); err != nil {
+			log.Errorf("failed to build batch, %d: %v", n, err)
+			os.Exit(1)
+		}
+		for _, r := range rows {
+			p.Debit(secretSeedKP.Address(), b.NativeAsset(), r.Amount)
+			p.Credit(r.Address, b.NativeAsset(), r.Amount)
+		}
+	}
+
+	if err := p.Print(os.Stdout); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+// writeRejects writes the rejected rows of all the batches in journal.
+func writeRejects(name string) (count int, err error) {
+	var records [][]string
+	for _, e := range journal.Entries() {
+		for _, r := range e.Rejects {
+			records = append(records, []string{r.Address, r.Amount, strconv.Itoa(r.Line), r.Reason})
+		}
+	}
+	if len(records) < 1 {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.WriteAll(records)
+	if err = w.Error(); err != nil {
+		return
+	}
+
+	count = len(records)
+	return
+}
+
+type batchResult struct {
+	batch int
+	err   error
+}
+
+// isTerminal checks whether the file is terminal; the progress bar is
+// redrawn only in terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+var stderrIsTerminal = isTerminal(os.Stderr)
+
+func printProgress(done, total int) {
+	const width = 40
+
+	// not in terminal, like the redirected log file, the plain line is printed
+	// by every 10 percent
+	if !stderrIsTerminal {
+		if done < total && 10*done/total == 10*(done-1)/total {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%d/%d batches\n", done, total)
+		return
+	}
+
+	filled := width * done / total
+	fmt.Fprintf(
+		os.Stderr,
+		"\r\033[K[%s%s] %d/%d batches",
+		strings.Repeat("#", filled),
+		strings.Repeat(".", width-filled),
+		done,
+		total,
+	)
+	if done == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func main() {
+	defer journal.Close()
+
+	for n, rows := range balanceData {
+		if e, found := journal.Entry(n); !found {
+			continue
+		} else if !sameRows(e.Rows, rows) {
+			log.Errorf("batch, %d in journal, '%s' is different with csv; the csv or -batch-size was changed?", n, flagJournal)
+			os.Exit(1)
+		} else if e.Status == boslib.JournalSuccess {
+			log.Infof("batch, %d was already confirmed in ledger, %d: %s", n, e.Ledger, e.Hash)
+		}
+	}
+
+	if errs := validate(); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Errorf("%d errors found in validation; nothing was submitted", len(errs))
+		os.Exit(1)
+	}
+
+	if flagDryRun {
+		dryRun()
+		os.Exit(0)
+	}
+
+	for n, rows := range balanceData {
+		if _, found := journal.Entry(n); found {
+			continue
+		}
+		if err := journal.Write(boslib.JournalEntry{Batch: n, Status: boslib.JournalPlanned, Rows: rows}); err != nil {
+			log.Errorf("failed to write journal, '%s': %v", flagJournal, err)
+			os.Exit(1)
+		}
+	}
+
+	if feeAuto {
+		var n int
+		for _, e := range journal.Entries() {
+			if e.Status != boslib.JournalSuccess {
+				n += len(e.Remaining())
+			}
+		}
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, n))
+	}
+
+	// with channels, the funding account is the source account of operations
+	var source string
+	if len(channelSeeds) > 0 {
+		source = secretSeedKP.Address()
+	}
+
+	jobs := make(chan int)
+	results := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < flagConcurrency; w++ {
+		channelSeed := flagSecretSeed
+		if len(channelSeeds) > 0 {
+			channelSeed = channelSeeds[w]
+		}
+
+		wg.Add(1)
+		go func(channelSeed string) {
+			defer wg.Done()
+
+			for n := range jobs {
+				results <- batchResult{batch: n, err: submitBatch(channelSeed, source, n)}
+			}
+		}(channelSeed)
+	}
+
+	go func() {
+		for n := range balanceData {
+			jobs <- n
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	marks := map[string]string{
+		boslib.ReportSuccess:  "O",
+		boslib.ReportRejected: "R",
+	}
+
+	var done int
+	for r := range results {
+		done++
+
+		e, _ := journal.Entry(r.batch)
+
+		// clear the progress bar before the results
+		if stderrIsTerminal {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+		for _, i := range e.ReportRows() {
+			mark, found := marks[i.Status]
+			if !found {
+				mark = "X"
+			}
+
+			if i.Status == boslib.ReportRejected {
+				fmt.Printf("(%s) %s : %s : %s\n", mark, i.Address, i.Amount, i.ResultCode)
+			} else {
+				fmt.Printf("(%s) %s : %s\n", mark, i.Address, i.Amount)
+			}
+		}
+		if r.err != nil {
+			log.Error(r.err)
+		}
+
+		printProgress(done, len(balanceData))
+	}
+
+	if count, err := writeRejects(flagRejects); err != nil {
+		log.Errorf("failed to write rejects, '%s': %v", flagRejects, err)
+		os.Exit(1)
+	} else if count > 0 {
+		log.Warnf("%d rows were rejected; see '%s'", count, flagRejects)
+	}
+
+	report := boslib.MakeReport(journal.Entries())
+	log.Infof(
+		"%d rows: succeeded=%d failed=%d rejected=%d pending=%d; sent %s in %d transactions, fees paid %s",
+		report.Summary.Rows,
+		report.Summary.Succeeded,
+		report.Summary.Failed,
+		report.Summary.Rejected,
+		report.Summary.Pending,
+		report.Summary.TotalAmount,
+		report.Summary.Transactions,
+		report.Summary.TotalFee,
+	)
+
+	if len(flagReport) > 0 {
+		if err := boslib.WriteReport(flagReport, report); err != nil {
+			log.Errorf("failed to write report, '%s': %v", flagReport, err)
+			os.Exit(1)
 		}
 	}
 }
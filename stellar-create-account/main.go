@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -19,7 +18,10 @@ import (
 	"github.com/spikeekips/stellar-utils/boslib"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
 )
 
 var log *logrus.Logger
@@ -34,9 +36,17 @@ var flagBalance string
 var flagAccountPublicAddress string
 var flagAccountSecretSeed string
 var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagMemoText string
+var flagMemoID string
+var flagMemoHash string
+var flagMemoReturn string
 var isRandom bool
 var accountPublicAddress string
-var accountData [][2]string // {{<public address>, <balance>}}
+var accountData [][2]string            // {{<public address>, <balance>}}
+var accountMemo map[string]boslib.Memo // memo by <public address>
 
 func usage(err error) {
 	if err != nil {
@@ -46,6 +56,9 @@ func usage(err error) {
 	os.Exit(1)
 }
 
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
 func checkAddressExists(horizonUrl, address string) (exists bool, err error) {
 	exists = false
 
@@ -74,8 +87,14 @@ func init() {
 
 	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
 	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
-	flags.Uint64Var(&flagFee, "fee", boslib.DefaultFee, "transaction fee")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
 	flags.StringVar(&flagCSVFile, "csv", "", "account csv file")
+	flags.StringVar(&flagMemoText, "memo-text", "", "text memo, up to 28 bytes")
+	flags.StringVar(&flagMemoID, "memo-id", "", "id memo, unsigned 64 bit integer")
+	flags.StringVar(&flagMemoHash, "memo-hash", "", "hash memo, hex encoded 32 bytes")
+	flags.StringVar(&flagMemoReturn, "memo-return", "", "return memo, hex encoded 32 bytes")
 
 	flags.Parse(os.Args[1:])
 
@@ -100,8 +119,8 @@ func init() {
 		flagBalance, utf8.RuneCountInString(fmt.Sprintf("%v", flagBalance)), flagBalance)
 	log.Debugf("given flagAccountPublicAddress: %T:%4d: %v",
 		flagAccountPublicAddress, utf8.RuneCountInString(flagAccountPublicAddress), flagAccountPublicAddress)
-	log.Debugf("given                  flagFee: %T:%4d: %v",
-		flagFee, utf8.RuneCountInString(fmt.Sprintf("%v", flagFee)), flagFee)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
 
 	// horizon
 	{
@@ -144,71 +163,73 @@ func init() {
 		}
 	}
 
+	// fee
 	{
-		if len(flagCSVFile) > 0 {
-			var err error
-			var f *os.File
-			if f, err = os.Open(flagCSVFile); err != nil {
-				log.Debugf("'%s' is not account csv file", flagCSVFile)
-			} else {
-				bf := bufio.NewReader(f)
-				line := 0
-				var l string
-				for {
-					s, isPrefix, err := bf.ReadLine()
-					if err != nil {
-						break
-					}
-					if isPrefix {
-						l = string(s)
-						continue
-
-					}
-					line += 1
-					l = string(s)
-
-					a := strings.Split(l, ",")
-					if len(a) < 2 {
-						continue
-					}
-					_, err = strconv.ParseFloat(strings.TrimSpace(a[1]), 64)
-					if err != nil {
-						log.Errorf("invalid account data; `balance` must be integer at line, %d, '%s'", line, a[1])
-						continue
-					}
-
-					address := strings.TrimSpace(a[0])
-					// check duplciation
-					for _, a := range accountData {
-						if a[0] == address {
-							usage(fmt.Errorf("<account's public address>, '%s' is duplicated at line, %d", address, line))
-						}
-					}
-
-					if exists, err := checkAddressExists(flagHorizon, address); err != nil {
-						usage(err)
-					} else if exists {
-						err = fmt.Errorf(
-							"account, '%s' is already registered in horizon, '%s' at line, %d",
-							address,
-							flagHorizon,
-							line,
-						)
-						usage(err)
-					}
-
-					if _, err = keypair.Parse(address); err != nil {
-						usage(fmt.Errorf("invalid <account's public address>, '%s' at line, %d: %v", address, line, err))
-					}
-					accountData = append(
-						accountData,
-						[2]string{
-							address,
-							strings.TrimSpace(a[1]),
-						},
-					)
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// memo; the memo of csv row overrides it
+	var memo boslib.Memo
+	{
+		var err error
+		if memo, err = boslib.MemoFromFlags(flagMemoText, flagMemoID, flagMemoHash, flagMemoReturn); err != nil {
+			usage(err)
+		}
+	}
+	accountMemo = map[string]boslib.Memo{}
+
+	// csv: <public address>,<balance>[,<memo>[,<memo type>]]
+	if len(flagCSVFile) > 0 {
+		records, err := boslib.ReadCSV(flagCSVFile, 2, "address", "amount", "memo", "memo_type")
+		if err != nil {
+			usage(fmt.Errorf("failed to read csv file, '%s': %v", flagCSVFile, err))
+		}
+
+		for _, r := range records {
+			if len(r.Address) < 1 || len(r.Amount) < 1 {
+				usage(fmt.Errorf("line %d: <account's public address> and <balance> must be given", r.Line))
+			}
+
+			if _, err = amount.Parse(r.Amount); err != nil {
+				usage(fmt.Errorf("line %d: invalid <balance>, '%s': %v", r.Line, r.Amount, err))
+			}
+
+			// check duplciation
+			for _, a := range accountData {
+				if a[0] == r.Address {
+					usage(fmt.Errorf("line %d: <account's public address>, '%s' is duplicated", r.Line, r.Address))
+				}
+			}
+
+			if _, err = keypair.Parse(r.Address); err != nil {
+				usage(fmt.Errorf("line %d: invalid <account's public address>, '%s': %v", r.Line, r.Address, err))
+			}
+
+			if exists, err := checkAddressExists(flagHorizon, r.Address); err != nil {
+				usage(err)
+			} else if exists {
+				usage(fmt.Errorf("line %d: account, '%s' is already registered in horizon, '%s'", r.Line, r.Address, flagHorizon))
+			}
+
+			accountMemo[r.Address] = memo
+			if len(r.Memo) > 0 || len(r.MemoType) > 0 {
+				if accountMemo[r.Address], err = boslib.ParseMemo(r.MemoType, r.Memo); err != nil {
+					usage(fmt.Errorf("line %d: invalid <memo>: %v", r.Line, err))
 				}
 			}
+
+			accountData = append(accountData, [2]string{r.Address, r.Amount})
 		}
 	}
 
@@ -217,14 +238,8 @@ func init() {
 		if len(accountData) < 1 {
 			flagBalance = flags.Arg(1)
 
-			var err error
-			var balance float64
-			balance, err = strconv.ParseFloat(flagBalance, 64)
-			if err != nil {
-				usage(err)
-			}
-			if balance < boslib.MinimumBalance {
-				usage(fmt.Errorf("<amount> must be higher than %0d", boslib.MinimumBalance))
+			if _, err := amount.Parse(flagBalance); err != nil {
+				usage(fmt.Errorf("invalid <amount>, '%s': %v", flagBalance, err))
 			}
 		}
 	}
@@ -284,6 +299,48 @@ func init() {
 					flagBalance,
 				},
 			)
+			accountMemo[flagAccountPublicAddress] = memo
+		}
+	}
+
+	// balance; the new accounts must have the minimum balance and the
+	// funding account must afford the amounts and fees over it's own minimum
+	// balance by the base reserve of the latest ledger.
+	{
+		ledger, err := boslib.LoadLatestLedger(flagHorizon)
+		if err != nil {
+			usage(err)
+		}
+		log.Debugf("base reserve and base fee of ledger, %d: %s, %d", ledger.Sequence, amount.String(xdr.Int64(ledger.BaseReserve)), ledger.BaseFee)
+
+		if err = ledger.CheckFee(flagFee); err != nil {
+			usage(fmt.Errorf("invalid -fee: %v", err))
+		}
+
+		minimum := ledger.MinimumBalance(0)
+
+		var total xdr.Int64
+		for _, a := range accountData {
+			balance, _ := amount.Parse(a[1])
+			if balance < minimum {
+				usage(fmt.Errorf(
+					"<amount> of '%s', '%s' must not be lower than the minimum balance, %s",
+					a[0],
+					a[1],
+					amount.String(minimum),
+				))
+			}
+			total += balance
+		}
+
+		account, err := boslib.LoadAccount(flagHorizon, secretSeedKP.Address())
+		if err != nil {
+			usage(err)
+		}
+
+		fees := xdr.Int64(flagFee) * xdr.Int64(len(accountData))
+		if err = ledger.CheckSpendable(account, total+fees); err != nil {
+			usage(err)
 		}
 	}
 
@@ -307,6 +364,39 @@ func init() {
 }
 
 func main() {
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, len(accountData)))
+	}
+
+	if flagDryRun {
+		p := boslib.NewPreview(flagHorizon)
+		for _, a := range accountData {
+			address, balance := a[0], a[1]
+			tx, err := p.Build(
+				flagSecretSeed,
+				flagSecretSeed,
+				flagNetworkPassphrase,
+				flagFee,
+				append(
+					append(accountMemo[address].Mutators(), timeBounds.Mutators()...),
+					boslib.CreateAccountOperations("", boslib.Balance{Address: address, Amount: balance})...,
+				)...,
+			)
+			if err != nil {
+				fmt.Printf("(X) Failed to build transaction, '%s', '%s': %v\n", address, balance, err)
+				os.Exit(1)
+			}
+			p.Debit(tx.Source, b.NativeAsset(), balance)
+			p.Credit(address, b.NativeAsset(), balance)
+		}
+
+		if err := p.Print(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	for _, a := range accountData {
 		address, balance := a[0], a[1]
 		resp, err := boslib.CreateAccount(
@@ -317,6 +407,7 @@ func main() {
 			flagNetworkPassphrase,
 			0,
 			flagFee,
+			append(accountMemo[address].Mutators(), timeBounds.Mutators()...)...,
 		)
 		if err != nil {
 			fmt.Printf("(X) Failed to create account, '%s', '%s': %v\n", address, balance, err)
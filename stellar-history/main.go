@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+var flags *flag.FlagSet
+
+var flagHorizon string
+var flagVerbose bool
+var flagType string
+var flagCursor string
+var flagOrder string
+var flagLimit int
+var flagSince string
+var flagUntil string
+var flagJSON bool
+
+var address keypair.KP
+var since time.Time
+var until time.Time
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.InfoLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address>")
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.StringVar(&flagType, "type", "transactions", "one of "+strings.Join(boslib.HistoryKinds, ", "))
+	flags.StringVar(&flagCursor, "cursor", "", "start after the cursor, paging token of record")
+	flags.StringVar(&flagOrder, "order", "desc", "'asc' or 'desc'")
+	flags.IntVar(&flagLimit, "limit", 10, "maximum number of records; 0 is unlimited")
+	flags.StringVar(&flagSince, "since", "", "records created at or after the time, RFC3339 or unix timestamp")
+	flags.StringVar(&flagUntil, "until", "", "records created before the time, RFC3339 or unix timestamp")
+	flags.BoolVar(&flagJSON, "json", false, "print the records in json, one record per line")
+
+	flags.Parse(os.Args[1:])
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 1 {
+		usage(errors.New("<public address> is missing"))
+	}
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given                 flagType: %T:%4d: %v", flagType, utf8.RuneCountInString(flagType), flagType)
+	log.Debugf("given               flagCursor: %T:%4d: %v", flagCursor, utf8.RuneCountInString(flagCursor), flagCursor)
+
+	{
+		var found bool
+		for _, k := range boslib.HistoryKinds {
+			if flagType == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			usage(fmt.Errorf("invalid -type, '%s'; must be one of %s", flagType, strings.Join(boslib.HistoryKinds, ", ")))
+		}
+	}
+
+	if flagOrder != "asc" && flagOrder != "desc" {
+		usage(fmt.Errorf("invalid -order, '%s'; must be 'asc' or 'desc'", flagOrder))
+	}
+
+	if flagLimit < 0 {
+		usage(fmt.Errorf("invalid -limit, %d; must be 0 or positive", flagLimit))
+	}
+
+	// time range
+	{
+		var err error
+		if len(flagSince) > 0 {
+			if since, err = boslib.ParseTime(flagSince); err != nil {
+				usage(fmt.Errorf("invalid -since: %v", err))
+			}
+		}
+		if len(flagUntil) > 0 {
+			if until, err = boslib.ParseTime(flagUntil); err != nil {
+				usage(fmt.Errorf("invalid -until: %v", err))
+			}
+		}
+		if !since.IsZero() && !until.IsZero() && !until.After(since) {
+			usage(errors.New("-until must be later than -since"))
+		}
+	}
+
+	// address
+	{
+		var err error
+		if address, err = keypair.Parse(strings.TrimSpace(flags.Arg(0))); err != nil {
+			usage(fmt.Errorf("invalid <public address>: %v", err))
+		}
+	}
+
+	// horizon
+	{
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if _, err := boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+
+		if exists, err := boslib.CheckAddressExists(flagHorizon, address.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf("account, '%s' does not exist", address.Address()))
+		}
+	}
+}
+
+// inRange checks the time of record with -since and -until; if the record
+// is out of range by the order, stop is true.
+func inRange(r boslib.HistoryRecord) (ok, stop bool) {
+	t, found := r.Time()
+	if !found {
+		return true, false
+	}
+
+	before := !since.IsZero() && t.Before(since)
+	after := !until.IsZero() && !t.Before(until)
+
+	switch {
+	case before:
+		return false, flagOrder == "desc"
+	case after:
+		return false, flagOrder == "asc"
+	}
+
+	return true, false
+}
+
+func main() {
+	pageLimit := boslib.MaxPageLimit
+	if flagLimit > 0 && flagLimit < pageLimit && since.IsZero() && until.IsZero() {
+		pageLimit = flagLimit
+	}
+
+	p := boslib.NewPager(
+		flagType,
+		boslib.CollectionURL(
+			flagHorizon,
+			boslib.PageQuery{Cursor: flagCursor, Order: flagOrder, Limit: pageLimit},
+			"accounts",
+			address.Address(),
+			flagType,
+		),
+	)
+
+	// the cursor is of the last printed record and it is displayed to continue
+	// only when the records are left by -limit
+	var n int
+	var cursor string
+	var limited bool
+	for p.Next() {
+		var r boslib.HistoryRecord
+		if err := p.Decode(&r); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		ok, stop := inRange(r)
+		if stop {
+			break
+		} else if !ok {
+			continue
+		}
+
+		if flagJSON {
+			var b bytes.Buffer
+			json.Compact(&b, p.Record())
+			fmt.Println(b.String())
+		} else {
+			fmt.Println(r.Summary())
+		}
+		cursor = p.Cursor()
+
+		n++
+		if flagLimit > 0 && n >= flagLimit {
+			limited = true
+			break
+		}
+	}
+	if err := p.Err(); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	if limited && len(cursor) > 0 {
+		log.Infof("%d %s; continue with '-cursor %s'", n, flagType, cursor)
+	} else {
+		log.Infof("%d %s", n, flagType)
+	}
+}
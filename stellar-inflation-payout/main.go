@@ -0,0 +1,428 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+var log *logrus.Logger
+var flags *flag.FlagSet
+
+var flagHorizon string
+var flagVerbose bool
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagSecretSeed string
+var flagLedger int
+var flagVotersCSVFile string
+var flagPoolFee float64
+var flagJournal string
+
+var networkPassphrase string
+var poolKP keypair.KP
+var journal *boslib.Journal
+var voterAddresses []string
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.InfoLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <pool secret seed> <inflation ledger> <voters csv>")
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.Float64Var(&flagPoolFee, "pool-fee", 0, "pool fee in percent, like 1.5")
+	flags.StringVar(&flagJournal, "journal", "", "journal file; default is '<pool address>-<inflation ledger>.journal'")
+
+	flags.Parse(os.Args[1:])
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 3 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+	flagVotersCSVFile = strings.TrimSpace(flags.Arg(2))
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given        flagVotersCSVFile: %T:%4d: %v",
+		flagVotersCSVFile, utf8.RuneCountInString(flagVotersCSVFile), flagVotersCSVFile)
+	log.Debugf("given              flagPoolFee: %T:%4d: %v",
+		flagPoolFee, utf8.RuneCountInString(fmt.Sprintf("%v", flagPoolFee)), flagPoolFee)
+
+	// inflation ledger
+	{
+		var err error
+		if flagLedger, err = strconv.Atoi(strings.TrimSpace(flags.Arg(1))); err != nil || flagLedger < 1 {
+			usage(fmt.Errorf("invalid <inflation ledger>, '%s'", flags.Arg(1)))
+		}
+	}
+
+	if flagPoolFee < 0 || flagPoolFee > 100 {
+		usage(fmt.Errorf("invalid -pool-fee, %v; must be 0 to 100", flagPoolFee))
+	}
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if poolKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <pool secret seed>: %v", err))
+		} else if string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <pool secret seed>, this is public address"))
+		}
+	}
+
+	// voters
+	{
+		records, err := boslib.ReadCSV(flagVotersCSVFile, 1, "address", "label")
+		if err != nil {
+			usage(fmt.Errorf("failed to read <voters csv>, '%s': %v", flagVotersCSVFile, err))
+		}
+
+		for _, r := range records {
+			if len(r.Address) < 1 {
+				continue
+			}
+
+			kp, err := keypair.Parse(r.Address)
+			if err != nil {
+				usage(fmt.Errorf("invalid voter address, '%s' at line, %d: %v", r.Address, r.Line, err))
+			}
+			voterAddresses = append(voterAddresses, kp.Address())
+		}
+	}
+
+	// journal; with -dry-run, the journal is not created
+	{
+		var err error
+		if len(flagJournal) < 1 {
+			flagJournal = fmt.Sprintf("%s-%d.journal", poolKP.Address(), flagLedger)
+		}
+
+		openJournal := boslib.OpenJournal
+		if flagDryRun {
+			openJournal = boslib.ReadJournal
+		}
+		if journal, err = openJournal(flagJournal); err != nil {
+			usage(fmt.Errorf("failed to open journal, '%s': %v", flagJournal, err))
+		}
+	}
+}
+
+// plan snapshots the balances of voters and divides the payout to the
+// shares by batch. The balances are of the latest ledger, not of the
+// inflation ledger; horizon does not keep the past balances. Nothing is
+// written to journal; if any voter can not be loaded, except the voter not
+// found, the whole plan fails.
+func plan() (entries []boslib.JournalEntry, err error) {
+	hash, err := boslib.FindInflationTransaction(flagHorizon, int32(flagLedger))
+	if err != nil {
+		return
+	}
+
+	payouts, err := boslib.LoadInflationPayouts(flagHorizon, hash)
+	if err != nil {
+		return
+	}
+
+	var payout xdr.Int64
+	for _, p := range payouts {
+		if p.Account != poolKP.Address() {
+			continue
+		}
+
+		var a xdr.Int64
+		if a, err = amount.Parse(p.Amount); err != nil {
+			err = fmt.Errorf("invalid payout amount, '%s': %v", p.Amount, err)
+			return
+		}
+		payout += a
+	}
+	if payout < 1 {
+		err = fmt.Errorf("'%s' received nothing from the inflation in ledger, %d", poolKP.Address(), flagLedger)
+		return
+	}
+
+	var accounts []boslib.Account
+	for _, a := range voterAddresses {
+		if a == poolKP.Address() {
+			continue
+		}
+
+		account, e := boslib.LoadAccount(flagHorizon, a)
+		if boslib.IsAccountNotFound(e) {
+			log.Warnf("voter, '%s' skipped: %v", a, e)
+			continue
+		} else if e != nil {
+			err = fmt.Errorf("failed to load voter, '%s': %v", a, e)
+			return
+		}
+		accounts = append(accounts, account)
+	}
+
+	ledger, err := boslib.LoadLatestLedger(flagHorizon)
+	if err != nil {
+		return
+	}
+
+	votes, err := boslib.CountInflationVotes(poolKP.Address(), accounts, ledger.TotalCoins)
+	if err != nil {
+		return
+	}
+
+	shares, fee := boslib.ComputePayoutShares(payout, int64(flagPoolFee*100+0.5), votes.Voters)
+	log.Infof(
+		"payout, %s: pool fee, %s and %d voters will receive %s by the current balances in ledger, %d, not by the balances in inflation ledger, %d",
+		amount.String(payout),
+		amount.String(fee),
+		len(shares),
+		amount.String(payout-fee),
+		ledger.Sequence,
+		flagLedger,
+	)
+
+	var rows []boslib.Balance
+	for _, s := range shares {
+		if s.Amount < 1 {
+			continue
+		}
+
+		rows = append(rows, boslib.Balance{Address: s.Address, Amount: amount.String(s.Amount)})
+		if len(rows) == boslib.MaxOperations {
+			entries = append(entries, boslib.JournalEntry{Batch: len(entries), Status: boslib.JournalPlanned, Rows: rows})
+			rows = nil
+		}
+	}
+	if len(rows) > 0 {
+		entries = append(entries, boslib.JournalEntry{Batch: len(entries), Status: boslib.JournalPlanned, Rows: rows})
+	}
+
+	for i := range entries {
+		entries[i].Batches = len(entries)
+	}
+
+	return
+}
+
+// writePlan writes the batches of plan to journal. If the journal has the
+// incomplete plan, which was interrupted while writing, it is replaced; no
+// batch of it was submitted.
+func writePlan(entries []boslib.JournalEntry) error {
+	if old := journal.Entries(); len(old) > 0 {
+		for _, e := range old {
+			if e.Status != boslib.JournalPlanned {
+				return fmt.Errorf(
+					"journal, '%s' has the incomplete plan, but batch, %d is already %s; check the journal",
+					flagJournal,
+					e.Batch,
+					e.Status,
+				)
+			}
+		}
+
+		if err := journal.Truncate(); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if err := journal.Write(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// payoutOperations makes the payments of batch with the time bounds.
+func payoutOperations(rows []boslib.Balance) []b.TransactionMutator {
+	ops := timeBounds.Mutators()
+	for _, r := range rows {
+		ops = append(ops, b.Payment(b.Destination{r.Address}, b.NativeAmount{r.Amount}))
+	}
+
+	return ops
+}
+
+// dryRun builds and signs the payouts, which are not paid yet, and prints
+// them without submitting. The new plan is not written to journal, so the
+// next run makes the plan again.
+func dryRun(entries []boslib.JournalEntry) {
+	p := boslib.NewPreview(flagHorizon)
+	for _, e := range entries {
+		if e.Status == boslib.JournalSuccess {
+			continue
+		}
+
+		if _, err := p.Build(flagSecretSeed, flagSecretSeed, networkPassphrase, flagFee, payoutOperations(e.Rows)...); err != nil {
+			log.Errorf("failed to build batch, %d: %v", e.Batch, err)
+			os.Exit(1)
+		}
+		for _, r := range e.Rows {
+			p.Debit(poolKP.Address(), b.NativeAsset(), r.Amount)
+			p.Credit(r.Address, b.NativeAsset(), r.Amount)
+		}
+	}
+
+	if err := p.Print(os.Stdout); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	defer journal.Close()
+
+	var entries []boslib.JournalEntry
+	if journal.Complete() {
+		log.Infof("resume from journal, '%s'", flagJournal)
+		entries = journal.Entries()
+	} else {
+		if len(journal.Entries()) > 0 {
+			log.Warnf("the plan in journal, '%s' is incomplete; plan again", flagJournal)
+		}
+
+		var err error
+		if entries, err = plan(); err == nil && !flagDryRun {
+			err = writePlan(entries)
+		}
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	if feeAuto {
+		var n int
+		for _, e := range entries {
+			if e.Status != boslib.JournalSuccess {
+				n += len(e.Rows)
+			}
+		}
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, n))
+	}
+
+	if flagDryRun {
+		dryRun(entries)
+		os.Exit(0)
+	}
+
+	// the batches already succeeded in journal were paid by the previous run
+	var paid, alreadyPaid xdr.Int64
+	var paidRows, alreadyPaidRows, failedRows, failedBatches int
+	for _, e := range journal.Entries() {
+		if e.Status == boslib.JournalSuccess {
+			for _, r := range e.Rows {
+				fmt.Printf("(-) %s : %s\n", r.Address, r.Amount)
+				a, _ := amount.Parse(r.Amount)
+				alreadyPaid += a
+				alreadyPaidRows++
+			}
+			continue
+		}
+
+		entry, err := boslib.SubmitBatch(flagHorizon, flagSecretSeed, networkPassphrase, flagFee, journal, e.Batch, payoutOperations(e.Rows)...)
+		if err != nil {
+			failedBatches++
+			failedRows += len(e.Rows)
+			log.Errorf("batch, %d failed: %v", e.Batch, err)
+		}
+
+		mark := "O"
+		if err != nil {
+			mark = "X"
+		}
+		for _, r := range e.Rows {
+			fmt.Printf("(%s) %s : %s\n", mark, r.Address, r.Amount)
+			if err == nil {
+				a, _ := amount.Parse(r.Amount)
+				paid += a
+				paidRows++
+			}
+		}
+		if err == nil {
+			log.Debugf("batch, %d in ledger, %d: %s", e.Batch, entry.Ledger, entry.Hash)
+		}
+	}
+
+	fmt.Printf(
+		"paid %s to %d voters; already paid %s to %d voters; %d voters in %d batches failed\n",
+		amount.String(paid),
+		paidRows,
+		amount.String(alreadyPaid),
+		alreadyPaidRows,
+		failedRows,
+		failedBatches,
+	)
+
+	if failedBatches > 0 {
+		fmt.Printf("run again with the same journal, '%s' to retry the failed batches\n", flagJournal)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -9,11 +10,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/spikeekips/stellar-utils/boslib"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
 	b "github.com/stellar/go/build"
 	"github.com/stellar/go/clients/horizon"
 	"github.com/stellar/go/keypair"
@@ -25,9 +28,26 @@ var flags *flag.FlagSet
 var flagHorizon string
 var flagVerbose bool
 var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagSetDest string
+var flagVotes string
+var flagCSVFile string
+var flagKeystore string
+var flagDaemon bool
+var flagLastLedger int
+var flagRetry int
+var flagRetryInterval time.Duration
+var flagPayoutAccounts string
 
 var secretSeed string
 var networkPassphrase string
+var mode string // "inflation", "set-dest" or "votes"
+var destination string
+
+// keys are the secret seeds for -set-dest and the addresses for -votes.
+var keys []string
 
 func usage(err error) {
 	if err != nil {
@@ -37,6 +57,73 @@ func usage(err error) {
 	os.Exit(1)
 }
 
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+// readCSVKeys reads the first column of csv file; it is secret seed or public
+// address.
+func readCSVKeys(f string) (ks []string, err error) {
+	var r *os.File
+	if r, err = os.Open(f); err != nil {
+		return
+	}
+	defer r.Close()
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	var records [][]string
+	if records, err = cr.ReadAll(); err != nil {
+		return
+	}
+
+	for _, l := range records {
+		if len(l) < 1 || len(strings.TrimSpace(l[0])) < 1 {
+			continue
+		}
+		ks = append(ks, strings.TrimSpace(l[0]))
+	}
+
+	return
+}
+
+// readKeystore reads the keystore file made by `stellar-keypair -short`; each
+// line is "<secret seed> <public address>". The secret seeds are returned for
+// -set-dest and the public addresses for -votes.
+func readKeystore(f string, seeds bool) (ks []string, err error) {
+	var body []byte
+	if body, err = ioutil.ReadFile(f); err != nil {
+		return
+	}
+
+	for i, l := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) < 1 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var kp keypair.KP
+		if kp, err = keypair.Parse(fields[0]); err != nil {
+			err = fmt.Errorf("line %d: invalid secret seed: %v", i+1, err)
+			return
+		} else if _, ok := kp.(*keypair.Full); !ok {
+			err = fmt.Errorf("line %d: not secret seed, this is public address", i+1)
+			return
+		} else if len(fields) > 1 && fields[1] != kp.Address() {
+			err = fmt.Errorf("line %d: public address, '%s' does not match with the secret seed", i+1, fields[1])
+			return
+		}
+
+		if seeds {
+			ks = append(ks, fields[0])
+		} else {
+			ks = append(ks, kp.Address())
+		}
+	}
+
+	return
+}
+
 func init() {
 	log = logrus.New()
 	log.SetLevel(logrus.InfoLevel)
@@ -45,12 +132,25 @@ func init() {
 
 	flags.Usage = func() {
 		fmt.Println(filepath.Base(os.Args[0]), "[options] <sender's secret seed> ")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -set-dest <destination> [<secret seed>...]")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -votes <destination> [<public address>...]")
 		flags.PrintDefaults()
 	}
 
 	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
 	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
-	flags.Uint64Var(&flagFee, "fee", boslib.DefaultFee, "transaction fee")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.StringVar(&flagSetDest, "set-dest", "", "set inflation destination of the accounts")
+	flags.StringVar(&flagVotes, "votes", "", "report the votes of the accounts for inflation destination")
+	flags.StringVar(&flagCSVFile, "csv", "", "csv file of secret seeds for -set-dest or public addresses for -votes")
+	flags.StringVar(&flagKeystore, "keystore", "", "keystore file made by 'stellar-keypair -short' for -set-dest or -votes")
+	flags.BoolVar(&flagDaemon, "daemon", false, "run inflation every week")
+	flags.IntVar(&flagLastLedger, "last-ledger", 0, "last inflation ledger; the next inflation time is calculated from it")
+	flags.IntVar(&flagRetry, "retry", 5, "maximum number of retries with transient failures")
+	flags.DurationVar(&flagRetryInterval, "retry-interval", 30*time.Second, "interval of retries")
+	flags.StringVar(&flagPayoutAccounts, "payout-accounts", "", "public addresses to report the payouts, separated by comma")
 
 	log.Debugf("arguments: %v", os.Args)
 	flags.Parse(os.Args[1:])
@@ -59,15 +159,31 @@ func init() {
 		log.Level = logrus.DebugLevel
 	}
 
-	if flags.NArg() < 1 {
-		usage(fmt.Errorf("insufficient arguments"))
+	switch {
+	case len(flagSetDest) > 0 && len(flagVotes) > 0:
+		usage(fmt.Errorf("-set-dest and -votes can not be used together"))
+	case len(flagSetDest) > 0:
+		mode = "set-dest"
+		destination = strings.TrimSpace(flagSetDest)
+	case len(flagVotes) > 0:
+		mode = "votes"
+		destination = strings.TrimSpace(flagVotes)
+	default:
+		mode = "inflation"
+		if flags.NArg() < 1 {
+			usage(fmt.Errorf("insufficient arguments"))
+		}
 	}
 
 	secretSeed = strings.TrimSpace(flags.Arg(0))
 
 	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
 	log.Debugf("given               secretSeed: %T:%4d: %v", secretSeed, utf8.RuneCountInString(secretSeed), secretSeed)
-	log.Debugf("given                  flagFee: %T:%4d: %v", flagFee, utf8.RuneCountInString(fmt.Sprintf("%v", flagFee)), flagFee)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v", feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+	log.Debugf("given              flagSetDest: %T:%4d: %v", flagSetDest, utf8.RuneCountInString(flagSetDest), flagSetDest)
+	log.Debugf("given                flagVotes: %T:%4d: %v", flagVotes, utf8.RuneCountInString(flagVotes), flagVotes)
+	log.Debugf("given              flagCSVFile: %T:%4d: %v", flagCSVFile, utf8.RuneCountInString(flagCSVFile), flagCSVFile)
+	log.Debugf("given             flagKeystore: %T:%4d: %v", flagKeystore, utf8.RuneCountInString(flagKeystore), flagKeystore)
 
 	// horizon
 	{
@@ -110,6 +226,68 @@ func init() {
 		}
 	}
 
+	// fee; the fee stats are not loaded for the votes report
+	if mode != "votes" {
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	if mode != "inflation" {
+		// destination
+		if kp, err := keypair.Parse(destination); err != nil {
+			usage(fmt.Errorf("invalid destination, '%s': %v", destination, err))
+		} else if exists, err := boslib.CheckAddressExists(flagHorizon, kp.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf("invalid destination, '%s'; the account is not found in network", destination))
+		} else {
+			destination = kp.Address()
+		}
+
+		// accounts
+		for _, a := range flags.Args() {
+			keys = append(keys, strings.TrimSpace(a))
+		}
+		if len(flagCSVFile) > 0 {
+			ks, err := readCSVKeys(flagCSVFile)
+			if err != nil {
+				usage(fmt.Errorf("failed to read csv file, '%s': %v", flagCSVFile, err))
+			}
+			keys = append(keys, ks...)
+		}
+		if len(flagKeystore) > 0 {
+			ks, err := readKeystore(flagKeystore, mode == "set-dest")
+			if err != nil {
+				usage(fmt.Errorf("failed to read keystore file, '%s': %v", flagKeystore, err))
+			}
+			keys = append(keys, ks...)
+		}
+		if len(keys) < 1 {
+			usage(fmt.Errorf("no accounts given"))
+		}
+
+		for _, k := range keys {
+			if _, err := keypair.Parse(k); err != nil {
+				usage(fmt.Errorf("invalid account, '%s': %v", k, err))
+			}
+			if mode == "set-dest" && string([]rune(k)[0]) != "S" {
+				usage(fmt.Errorf("not <secret seed>, '%s'; this is public address", k))
+			}
+		}
+
+		return
+	}
+
 	// secret seed
 	{
 		var err error
@@ -131,44 +309,290 @@ func init() {
 	}
 }
 
-func main() {
+func submitInflation() (resp horizon.TransactionSuccess, err error) {
 	nc := boslib.MakeNetwork(flagHorizon)
 
-	var err error
-
-	tx, err := b.Transaction(
+	muts := []b.TransactionMutator{
 		b.SourceAccount{secretSeed},
 		b.Network{Passphrase: networkPassphrase},
 		b.AutoSequence{nc},
 		b.BaseFee{Amount: flagFee},
 		b.Inflation(),
-	)
+	}
+	muts = append(muts, timeBounds.Mutators()...)
+
+	tx, err := b.Transaction(muts...)
 	if err != nil {
-		fmt.Printf("failed to make tranaction: %s\n", err)
-		os.Exit(1)
+		err = fmt.Errorf("failed to make tranaction: %v", err)
+		return
 	}
 
 	tx.NetworkPassphrase = networkPassphrase
 
 	txe, err := tx.Sign(secretSeed)
 	if err != nil {
-		fmt.Printf("failed to sign: %s\n", err)
-		os.Exit(1)
+		err = fmt.Errorf("failed to sign: %v", err)
+		return
 	}
 
 	var txeB64 string
 	if txeB64, err = txe.Base64(); err != nil {
-		fmt.Printf("failed to txeB64: %s\n", err)
-		os.Exit(1)
+		err = fmt.Errorf("failed to txeB64: %v", err)
+		return
+	}
+
+	return nc.SubmitTransaction(txeB64)
+}
+
+// submitInflationWithRetry submits the inflation again with the transient
+// errors like network failure.
+func submitInflationWithRetry() (resp horizon.TransactionSuccess, err error) {
+	for i := 0; ; i++ {
+		if resp, err = submitInflation(); err == nil {
+			return
+		}
+
+		if !boslib.IsTransientError(err) || i >= flagRetry {
+			return
+		}
+
+		log.Warnf("failed to submit inflation, retry after %s(%d/%d): %v", flagRetryInterval, i+1, flagRetry, err)
+		time.Sleep(flagRetryInterval)
+	}
+}
+
+func reportPayouts(resp horizon.TransactionSuccess) {
+	payouts, err := boslib.LoadInflationPayouts(flagHorizon, resp.Hash)
+	if err != nil {
+		log.Errorf("failed to load inflation payouts: %v", err)
+		return
+	}
+
+	accounts := map[string]bool{}
+	for _, a := range strings.Split(flagPayoutAccounts, ",") {
+		if a = strings.TrimSpace(a); len(a) > 0 {
+			accounts[a] = true
+		}
 	}
 
-	var resp horizon.TransactionSuccess
-	if resp, err = nc.SubmitTransaction(txeB64); err != nil {
-		log.Debugf("failed to SubmitTransaction: %s", err)
+	var n int
+	for _, p := range payouts {
+		if len(accounts) > 0 && !accounts[p.Account] {
+			continue
+		}
+		n++
+		fmt.Printf("payout: %s %20s\n", p.Account, p.Amount)
+	}
+
+	log.Infof("%d payouts of %d received by the accounts in ledger, %d", n, len(payouts), resp.Ledger)
+}
+
+func runDaemon() {
+	var next time.Time
+	if flagLastLedger > 0 {
+		ledger, err := boslib.LoadLedger(flagHorizon, int32(flagLastLedger))
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		next = boslib.NextInflationTime(ledger.ClosedAt)
+	} else {
+		next = time.Now()
+	}
+
+	for {
+		if d := time.Until(next); d > 0 {
+			log.Infof("next inflation will be at %s, after %s", next.UTC(), d)
+			time.Sleep(d)
+		}
+
+		// the fee chosen at start can be old for weeks
+		if feeAuto {
+			if fee, _, err := feeFlags.Resolve(flagHorizon); err != nil {
+				log.Warnf("failed to resolve fee, the previous fee, %d is used: %v", flagFee, err)
+			} else {
+				flagFee = fee
+				log.Infof("fee: %s", boslib.FeeSummary(flagFee, 1))
+			}
+		}
+
+		resp, err := submitInflationWithRetry()
+		switch {
+		case err == nil:
+			log.Infof("inflation occurred in ledger, %d: %s", resp.Ledger, resp.Hash)
+			reportPayouts(resp)
+		case boslib.HasOperationCode(err, "inflation_not_time"):
+			// the inflation already ran by the others or the time of network
+			// is not the inflation time yet
+			since := boslib.LastInflationTime(next)
+			hash, ran, err := boslib.FindInflationSince(flagHorizon, since)
+			if err != nil {
+				log.Errorf("failed to find inflation since %s, retry after %s: %v", since.UTC(), flagRetryInterval, err)
+				next = time.Now().Add(flagRetryInterval)
+				continue
+			} else if !ran {
+				log.Infof("inflation is not time yet, retry after %s", flagRetryInterval)
+				next = time.Now().Add(flagRetryInterval)
+				continue
+			}
+			log.Infof("inflation already ran since %s: %s", since.UTC(), hash)
+		default:
+			log.Errorf("failed to run inflation, retry after %s: %v", flagRetryInterval, err)
+			next = time.Now().Add(flagRetryInterval)
+			continue
+		}
+
+		closedAt := time.Now()
+		if ledger, err := boslib.LoadLatestLedger(flagHorizon); err != nil {
+			log.Errorf("failed to load latest ledger: %v", err)
+		} else {
+			closedAt = ledger.ClosedAt
+		}
+		next = boslib.NextInflationTime(closedAt)
+	}
+}
+
+func main() {
+	switch mode {
+	case "set-dest":
+		setDestination()
+		os.Exit(0)
+	case "votes":
+		reportVotes()
+		os.Exit(0)
+	}
+
+	if flagDryRun {
+		if feeAuto {
+			fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+		}
+
+		err := boslib.PreviewOperations(
+			os.Stdout,
+			flagHorizon,
+			secretSeed,
+			networkPassphrase,
+			flagFee,
+			append(timeBounds.Mutators(), b.Inflation())...,
+		)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if flagDaemon {
+		runDaemon()
+		os.Exit(0)
+	}
+
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+	}
+
+	resp, err := submitInflation()
+	if boslib.HasOperationCode(err, "inflation_not_time") {
+		log.Errorf("inflation is not time yet or already ran: %v", err)
+		os.Exit(1)
+	} else if err != nil {
+		log.Errorf("failed to submit inflation: %v", err)
 		os.Exit(1)
 	}
 
 	log.Debugf("inflation occurred: %v", resp.Ledger)
+	reportPayouts(resp)
 
 	os.Exit(0)
 }
+
+func setDestination() {
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, len(keys)))
+	}
+
+	if flagDryRun {
+		p := boslib.NewPreview(flagHorizon)
+		for _, k := range keys {
+			op := b.SetOptions(b.InflationDest(destination))
+			if _, err := p.Build(k, k, networkPassphrase, flagFee, append(timeBounds.Mutators(), op)...); err != nil {
+				log.Error(err)
+				os.Exit(1)
+			}
+		}
+
+		if err := p.Print(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var failed int
+	for _, k := range keys {
+		kp, _ := keypair.Parse(k)
+
+		resp, err := boslib.SetInflationDestination(flagHorizon, k, destination, networkPassphrase, 0, flagFee, timeBounds.Mutators()...)
+		if err != nil {
+			failed++
+			fmt.Printf("(X) %s: %v\n", kp.Address(), err)
+			continue
+		}
+		log.Debugf("transaction posted in ledger: %v", resp.Ledger)
+
+		fmt.Printf("(O) %s\n", kp.Address())
+	}
+
+	if failed > 0 {
+		log.Errorf("failed to set inflation destination of %d accounts", failed)
+		os.Exit(1)
+	}
+}
+
+func reportVotes() {
+	ledger, err := boslib.LoadLatestLedger(flagHorizon)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	var accounts []boslib.Account
+	for _, k := range keys {
+		kp, _ := keypair.Parse(k)
+
+		account, err := boslib.LoadAccount(flagHorizon, kp.Address())
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+
+	votes, err := boslib.CountInflationVotes(destination, accounts, ledger.TotalCoins)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d of %d accounts vote for '%s':\n", len(votes.Voters), len(keys), destination)
+	for _, v := range votes.Voters {
+		fmt.Printf("  %s %20s\n", v.Address, amount.String(v.Balance))
+	}
+
+	var result string
+	if votes.IsWinner() {
+		result = "meets"
+	} else {
+		result = "does not meet"
+	}
+
+	fmt.Printf(
+		"total votes: %s; it %s the inflation winner threshold, %s(%0.2f%% of total coins, %s in ledger, %d)\n",
+		amount.String(votes.Total),
+		result,
+		amount.String(votes.Threshold),
+		boslib.InflationWinnerRatio*100,
+		ledger.TotalCoins,
+		ledger.Sequence,
+	)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+
+var flags *flag.FlagSet
+var flagVerbose bool
+var flagHorizon string
+var flagSecretSeed string
+var flagName string
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagHex bool
+var flagFile bool
+var flagDelete bool
+
+var networkPassphrase string
+var secretSeedKP keypair.KP
+var account boslib.Account
+var value []byte
+var isList bool
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.ErrorLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address or secret seed>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <secret seed> <name> <value>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -delete <secret seed> <name>")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.BoolVar(&flagHex, "hex", false, "<value> is hex string; in list, values are displayed in hex")
+	flags.BoolVar(&flagFile, "file", false, "<value> is file name; the file content will be the value")
+	flags.BoolVar(&flagDelete, "delete", false, "delete data entry")
+
+	flags.Parse(os.Args[1:])
+
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 1 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	if flagDelete && flags.NArg() < 2 {
+		usage(fmt.Errorf("insufficient arguments; <name> is missing"))
+	}
+
+	isList = flags.NArg() == 1
+	if !isList && !flagDelete && flags.NArg() < 3 {
+		usage(fmt.Errorf("insufficient arguments; <value> is missing"))
+	}
+	if flagHex && flagFile {
+		usage(fmt.Errorf("-hex and -file can not be used together"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+	flagName = strings.TrimSpace(flags.Arg(1))
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given                 flagName: %T:%4d: %v", flagName, utf8.RuneCountInString(flagName), flagName)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee; the fee stats are not loaded for listing
+	if !isList {
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if !isList && string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+
+		if account, err = boslib.LoadAccount(flagHorizon, secretSeedKP.Address()); err != nil {
+			usage(err)
+		}
+	}
+
+	if isList {
+		return
+	}
+
+	// value
+	{
+		var err error
+		v := flags.Arg(2)
+		switch {
+		case flagDelete:
+			if _, found := account.Data[flagName]; !found {
+				usage(fmt.Errorf("data entry, '%s' does not exist", flagName))
+			}
+		case flagHex:
+			if value, err = hex.DecodeString(strings.TrimSpace(v)); err != nil {
+				usage(fmt.Errorf("invalid hex <value>: %v", err))
+			}
+		case flagFile:
+			if value, err = ioutil.ReadFile(v); err != nil {
+				usage(fmt.Errorf("failed to read <value> file: %v", err))
+			}
+		default:
+			value = []byte(v)
+		}
+
+		if err = boslib.CheckDataEntry(flagName, value); err != nil {
+			usage(err)
+		}
+	}
+}
+
+func list() {
+	var names []string
+	for name := range account.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%d data entries of '%s':\n", len(names), account.ID)
+	for _, name := range names {
+		v, err := boslib.DecodeDataValue(account.Data[name])
+		if err != nil {
+			log.Errorf("invalid value of '%s' received: %v", name, err)
+			continue
+		}
+
+		var s string
+		if flagHex {
+			s = hex.EncodeToString(v)
+		} else {
+			s = boslib.FormatDataValue(v)
+		}
+		fmt.Printf("  %s: %s\n", name, s)
+	}
+}
+
+func main() {
+	if isList {
+		list()
+		os.Exit(0)
+	}
+
+	var op b.TransactionMutator
+	var action string
+	if flagDelete {
+		op = b.ClearData(flagName)
+		action = "deleted"
+	} else {
+		op = b.SetData(flagName, value)
+		if _, found := account.Data[flagName]; found {
+			action = "updated"
+		} else {
+			action = "set"
+		}
+	}
+
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+	}
+
+	if flagDryRun {
+		if err := boslib.PreviewOperations(os.Stdout, flagHorizon, flagSecretSeed, networkPassphrase, flagFee, append(timeBounds.Mutators(), op)...); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	resp, err := boslib.SubmitOperations(
+		flagHorizon,
+		flagSecretSeed,
+		networkPassphrase,
+		0,
+		flagFee,
+		append(timeBounds.Mutators(), op)...,
+	)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	log.Debugf("transaction posted in ledger: %v", resp.Ledger)
+
+	fmt.Printf("data entry, '%s' of '%s' %s\n", flagName, account.ID, action)
+
+	os.Exit(0)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+
+var flags *flag.FlagSet
+var flagVerbose bool
+var flagHorizon string
+var flagSecretSeed string
+var flagDestinationAddress string
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagPlan bool
+var flagYes bool
+
+var networkPassphrase string
+var secretSeedKP keypair.KP
+var destinationKP keypair.KP
+var account boslib.Account
+var steps []boslib.MergeStep
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.ErrorLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <secret seed of account to merge> <destination public address>")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.BoolVar(&flagPlan, "plan", false, "show the plan only, not merge")
+	flags.BoolVar(&flagYes, "yes", false, "merge without confirmation")
+
+	flags.Parse(os.Args[1:])
+
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 2 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+	flagDestinationAddress = strings.TrimSpace(flags.Arg(1))
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given   flagDestinationAddress: %T:%4d: %v",
+		flagDestinationAddress, utf8.RuneCountInString(flagDestinationAddress), flagDestinationAddress)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee; the fee stats are not loaded for -plan
+	if !flagPlan {
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+	}
+
+	// destination public address
+	{
+		var err error
+		if destinationKP, err = keypair.Parse(flagDestinationAddress); err != nil {
+			usage(fmt.Errorf("malformed <destination public address>: %v", err))
+		}
+
+		if destinationKP.Address() == secretSeedKP.Address() {
+			usage(fmt.Errorf("<destination public address> is same with the account to merge"))
+		}
+
+		if exists, err := boslib.CheckAddressExists(flagHorizon, destinationKP.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf(
+				"invalid <destination public address>, '%s'; the account is not found in network",
+				flagDestinationAddress,
+			))
+		}
+	}
+
+	// plan
+	{
+		var err error
+		if account, err = boslib.LoadAccount(flagHorizon, secretSeedKP.Address()); err != nil {
+			usage(err)
+		}
+
+		offers, err := boslib.LoadAccountOffers(flagHorizon, secretSeedKP.Address())
+		if err != nil {
+			usage(err)
+		}
+
+		if steps, err = boslib.MakeMergePlan(account, offers, destinationKP.Address()); err != nil {
+			usage(err)
+		}
+	}
+}
+
+// dryRun builds and signs the transactions of the merge plan and prints
+// them without submitting. The non-native balances are returned to the
+// issuers and the remaining lumens go to the destination.
+func dryRun(chunks [][]boslib.MergeStep) {
+	p := boslib.NewPreview(flagHorizon)
+	for i, c := range chunks {
+		var ops []b.TransactionMutator
+		for _, s := range c {
+			ops = append(ops, s.Operation)
+		}
+
+		if _, err := p.Build(flagSecretSeed, flagSecretSeed, networkPassphrase, flagFee, append(timeBounds.Mutators(), ops...)...); err != nil {
+			fmt.Printf("(X) failed to build transaction %d: %v\n", i, err)
+			os.Exit(1)
+		}
+	}
+
+	for _, a := range account.Balances {
+		if a.AssetType == "native" {
+			continue
+		}
+		p.Debit(secretSeedKP.Address(), a.Asset(), a.Balance)
+		p.Credit(a.AssetIssuer, a.Asset(), a.Balance)
+	}
+
+	native, err := amount.Parse(account.NativeBalance())
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	remaining := amount.String(native - p.TotalFee())
+	p.Debit(secretSeedKP.Address(), b.NativeAsset(), remaining)
+	p.Credit(destinationKP.Address(), b.NativeAsset(), remaining)
+
+	if err := p.Print(os.Stdout); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	var chunks [][]boslib.MergeStep
+	for i := 0; i < len(steps); i += boslib.MaxOperations {
+		end := i + boslib.MaxOperations
+		if end > len(steps) {
+			end = len(steps)
+		}
+		chunks = append(chunks, steps[i:end])
+	}
+
+	fmt.Printf("merge plan of '%s', %d transactions:\n", secretSeedKP.Address(), len(chunks))
+	var n int
+	for i, c := range chunks {
+		fmt.Printf("  transaction %d:\n", i)
+		for _, s := range c {
+			n++
+			fmt.Printf("    %3d: %s\n", n, s.Description)
+		}
+	}
+
+	if flagPlan {
+		os.Exit(0)
+	}
+
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, len(steps)))
+	}
+
+	if flagDryRun {
+		dryRun(chunks)
+		os.Exit(0)
+	}
+
+	if !flagYes {
+		fmt.Fprintf(os.Stderr, "network: %s\n", boslib.NetworkName(networkPassphrase))
+		answer, err := boslib.Ask(os.Stdin, os.Stderr, "type 'yes' to merge: ")
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		} else if answer != "yes" {
+			log.Error("merge was not confirmed; nothing was submitted")
+			os.Exit(1)
+		}
+	}
+
+	for i, c := range chunks {
+		var ops []b.TransactionMutator
+		for _, s := range c {
+			ops = append(ops, s.Operation)
+		}
+
+		resp, err := boslib.SubmitOperations(
+			flagHorizon,
+			flagSecretSeed,
+			networkPassphrase,
+			0,
+			flagFee,
+			append(timeBounds.Mutators(), ops...)...,
+		)
+		if err != nil {
+			fmt.Printf("(X) transaction %d failed: %v\n", i, err)
+			os.Exit(1)
+		}
+		fmt.Printf("(O) transaction %d posted in ledger, %d: %s\n", i, resp.Ledger, resp.Hash)
+	}
+
+	fmt.Printf("account, '%s' was merged into '%s'\n", secretSeedKP.Address(), destinationKP.Address())
+
+	os.Exit(0)
+}
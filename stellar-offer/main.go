@@ -0,0 +1,322 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+
+var flags *flag.FlagSet
+var flagVerbose bool
+var flagHorizon string
+var flagSecretSeed string
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagPassive bool
+var flagUpdate uint64
+var flagCancel uint64
+var flagDepth int
+
+var networkPassphrase string
+var secretSeedKP keypair.KP
+var offers []boslib.Offer
+var selling b.Asset
+var buying b.Asset
+var offerAmount string
+var offerPrice boslib.OfferPrice
+var isList bool
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.ErrorLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address or secret seed>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <secret seed> <selling asset> <buying asset> <amount> <price>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -cancel <offer id> <secret seed>")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.BoolVar(&flagPassive, "passive", false, "create passive offer")
+	flags.Uint64Var(&flagUpdate, "update", 0, "offer id to update")
+	flags.Uint64Var(&flagCancel, "cancel", 0, "offer id to cancel")
+	flags.IntVar(&flagDepth, "depth", 5, "depth of orderbook to show")
+
+	flags.Parse(os.Args[1:])
+
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 1 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	isList = flags.NArg() == 1 && flagCancel < 1
+	if !isList && flagCancel < 1 && flags.NArg() < 5 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+	if flagPassive && (flagUpdate > 0 || flagCancel > 0) {
+		usage(fmt.Errorf("-passive can not be used with -update or -cancel"))
+	}
+	if flagUpdate > 0 && flagCancel > 0 {
+		usage(fmt.Errorf("-update and -cancel can not be used together"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee; the fee stats are not loaded for listing
+	if !isList {
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if !isList && string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+
+		if offers, err = boslib.LoadAccountOffers(flagHorizon, secretSeedKP.Address()); err != nil {
+			usage(err)
+		}
+	}
+
+	if isList {
+		return
+	}
+
+	// cancel
+	if flagCancel > 0 {
+		o, found := findOffer(flagCancel)
+		if !found {
+			usage(fmt.Errorf("offer, %d is not found in the open offers of '%s'", flagCancel, secretSeedKP.Address()))
+		}
+
+		selling, buying = o.SellingAsset(), o.BuyingAsset()
+		offerAmount = "0"
+		offerPrice = o.PriceR
+
+		return
+	}
+
+	// offer
+	{
+		var err error
+		if selling, err = boslib.ParseAsset(flags.Arg(1)); err != nil {
+			usage(fmt.Errorf("invalid <selling asset>: %v", err))
+		}
+		if buying, err = boslib.ParseAsset(flags.Arg(2)); err != nil {
+			usage(fmt.Errorf("invalid <buying asset>: %v", err))
+		}
+		if boslib.AssetEqual(selling, buying) {
+			usage(fmt.Errorf("<selling asset> and <buying asset> are same"))
+		}
+
+		offerAmount = strings.TrimSpace(flags.Arg(3))
+		if a, err := amount.Parse(offerAmount); err != nil {
+			usage(fmt.Errorf("invalid <amount>, '%s': %v", offerAmount, err))
+		} else if a < 1 {
+			usage(fmt.Errorf("invalid <amount>, '%s'; must be positive, to cancel, use -cancel", offerAmount))
+		}
+
+		if offerPrice, err = boslib.ParsePrice(flags.Arg(4)); err != nil {
+			usage(fmt.Errorf("invalid <price>: %v", err))
+		}
+
+		if flagUpdate > 0 {
+			if _, found := findOffer(flagUpdate); !found {
+				usage(fmt.Errorf("offer, %d is not found in the open offers of '%s'", flagUpdate, secretSeedKP.Address()))
+			}
+		}
+	}
+}
+
+func findOffer(id uint64) (boslib.Offer, bool) {
+	for _, o := range offers {
+		if i, err := o.OfferID(); err == nil && i == id {
+			return o, true
+		}
+	}
+
+	return boslib.Offer{}, false
+}
+
+func list() {
+	fmt.Printf("%d open offers of '%s':\n", len(offers), secretSeedKP.Address())
+	for _, o := range offers {
+		fmt.Printf(
+			"%12s: selling %20s %s for %s at %s(%d/%d)\n",
+			o.ID,
+			o.Amount,
+			boslib.AssetString(o.SellingAsset()),
+			boslib.AssetString(o.BuyingAsset()),
+			o.Price,
+			o.PriceR.N,
+			o.PriceR.D,
+		)
+	}
+}
+
+// printOrderBook shows the orderbook around the price of offer. The offer
+// sells the selling asset, so it will be placed among the asks.
+func printOrderBook() {
+	orderBook, err := boslib.LoadOrderBook(flagHorizon, selling, buying, flagDepth)
+	if err != nil {
+		log.Errorf("failed to load orderbook: %v", err)
+		return
+	}
+
+	fmt.Printf(
+		"orderbook, selling %s for %s:\n",
+		boslib.AssetString(selling),
+		boslib.AssetString(buying),
+	)
+
+	line := func(kind string, e boslib.OrderBookEntry) {
+		fmt.Printf("  %4s %20s %20s\n", kind, e.Price, e.Amount)
+	}
+
+	fmt.Printf("  %4s %20s %20s\n", "", "price", "amount")
+	for i := len(orderBook.Asks) - 1; i >= 0; i-- {
+		line("ask", orderBook.Asks[i])
+	}
+	fmt.Printf(
+		"  %4s %20s %20s <- %s\n",
+		">>>",
+		strconv.FormatFloat(offerPrice.Float(), 'f', 7, 64),
+		offerAmount,
+		offerPrice,
+	)
+	for _, e := range orderBook.Bids {
+		line("bid", e)
+	}
+
+	if len(orderBook.Bids) > 0 && orderBook.Bids[0].PriceR.Cmp(offerPrice) >= 0 {
+		fmt.Println("  * the offer crosses the highest bid; it will be taken immediately")
+	}
+}
+
+func main() {
+	if isList {
+		list()
+		os.Exit(0)
+	}
+
+	var offerID uint64
+	var action string
+	switch {
+	case flagCancel > 0:
+		offerID = flagCancel
+		action = "cancelled"
+	case flagUpdate > 0:
+		offerID = flagUpdate
+		action = "updated"
+	default:
+		action = "created"
+	}
+
+	if flagCancel < 1 {
+		printOrderBook()
+	}
+
+	op, err := boslib.OfferOperation(selling, buying, offerAmount, offerPrice, offerID, flagPassive)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+	}
+
+	if flagDryRun {
+		if err := boslib.PreviewOperations(os.Stdout, flagHorizon, flagSecretSeed, networkPassphrase, flagFee, append(timeBounds.Mutators(), op)...); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	resp, err := boslib.SubmitOperations(
+		flagHorizon,
+		flagSecretSeed,
+		networkPassphrase,
+		0,
+		flagFee,
+		append(timeBounds.Mutators(), op)...,
+	)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	log.Debugf("transaction posted in ledger: %v", resp.Ledger)
+
+	fmt.Printf("offer %s in ledger, %d: %s\n", action, resp.Ledger, resp.Hash)
+
+	os.Exit(0)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+
+var flags *flag.FlagSet
+var flagVerbose bool
+var flagHorizon string
+var flagSecretSeed string
+var flagReceiverAddress string
+var flagSourceAsset string
+var flagDestinationAsset string
+var flagDestinationAmount string
+var flagMaxSend string
+var flagSlippage float64
+var flagAuto bool
+var flagPath int
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+
+var networkPassphrase string
+var secretSeedKP keypair.KP
+var receiverKP keypair.KP
+var sourceAsset b.Asset
+var destinationAsset b.Asset
+var paths []boslib.PaymentPath
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.ErrorLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Println(
+			filepath.Base(os.Args[0]),
+			"[options] <sender's secret seed> <receiver's public address> <destination asset> <destination amount>",
+		)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.StringVar(&flagSourceAsset, "source-asset", "native", "source asset, 'native' or '<code>:<issuer>'")
+	flags.StringVar(&flagMaxSend, "max-send", "", "maximum amount of source asset to send")
+	flags.Float64Var(&flagSlippage, "slippage", 1, "slippage tolerance in percent over the source amount of path without -max-send")
+	flags.BoolVar(&flagAuto, "auto", false, "pick the cheapest path automatically")
+	flags.IntVar(&flagPath, "path", -1, "index of path to pick")
+
+	flags.Parse(os.Args[1:])
+
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 4 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+	flagReceiverAddress = strings.TrimSpace(flags.Arg(1))
+	flagDestinationAsset = strings.TrimSpace(flags.Arg(2))
+	flagDestinationAmount = strings.TrimSpace(flags.Arg(3))
+	flagMaxSend = strings.TrimSpace(flagMaxSend)
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given      flagReceiverAddress: %T:%4d: %v",
+		flagReceiverAddress, utf8.RuneCountInString(flagReceiverAddress), flagReceiverAddress)
+	log.Debugf("given          flagSourceAsset: %T:%4d: %v",
+		flagSourceAsset, utf8.RuneCountInString(flagSourceAsset), flagSourceAsset)
+	log.Debugf("given     flagDestinationAsset: %T:%4d: %v",
+		flagDestinationAsset, utf8.RuneCountInString(flagDestinationAsset), flagDestinationAsset)
+	log.Debugf("given    flagDestinationAmount: %T:%4d: %v",
+		flagDestinationAmount, utf8.RuneCountInString(flagDestinationAmount), flagDestinationAmount)
+	log.Debugf("given              flagMaxSend: %T:%4d: %v", flagMaxSend, utf8.RuneCountInString(flagMaxSend), flagMaxSend)
+	log.Debugf("given             flagSlippage: %T:%4d: %v",
+		flagSlippage, utf8.RuneCountInString(fmt.Sprintf("%v", flagSlippage)), flagSlippage)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+
+	// assets and amounts
+	{
+		var err error
+		if sourceAsset, err = boslib.ParseAsset(flagSourceAsset); err != nil {
+			usage(fmt.Errorf("invalid -source-asset: %v", err))
+		}
+		if destinationAsset, err = boslib.ParseAsset(flagDestinationAsset); err != nil {
+			usage(fmt.Errorf("invalid <destination asset>: %v", err))
+		}
+		if _, err = amount.Parse(flagDestinationAmount); err != nil {
+			usage(fmt.Errorf("invalid <destination amount>, '%s': %v", flagDestinationAmount, err))
+		}
+		if len(flagMaxSend) > 0 {
+			if _, err = amount.Parse(flagMaxSend); err != nil {
+				usage(fmt.Errorf("invalid -max-send, '%s': %v", flagMaxSend, err))
+			}
+		}
+		if flagSlippage < 0 || flagSlippage > 100 {
+			usage(fmt.Errorf("invalid -slippage, %v; must be 0 to 100", flagSlippage))
+		}
+	}
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+
+		if exists, err := boslib.CheckAddressExists(flagHorizon, secretSeedKP.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf(
+				"invalid <secret seed>, '%s'; the account is not found in network",
+				flagSecretSeed,
+			))
+		}
+	}
+
+	// receiver's public address
+	{
+		var err error
+		if receiverKP, err = keypair.Parse(flagReceiverAddress); err != nil {
+			usage(fmt.Errorf("malformed <receiver's public address>: %v", err))
+		}
+
+		if exists, err := boslib.CheckAddressExists(flagHorizon, receiverKP.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf(
+				"invalid <receiver's address>, '%s'; the account is not found in network",
+				flagReceiverAddress,
+			))
+		}
+	}
+
+	// paths
+	{
+		var err error
+		paths, err = boslib.FindPaymentPaths(
+			flagHorizon,
+			secretSeedKP.Address(),
+			receiverKP.Address(),
+			sourceAsset,
+			destinationAsset,
+			flagDestinationAmount,
+		)
+		if err != nil {
+			usage(err)
+		}
+
+		if len(paths) < 1 {
+			log.Errorf(
+				"no path found to pay %s %s with %s",
+				flagDestinationAmount,
+				boslib.AssetString(destinationAsset),
+				boslib.AssetString(sourceAsset),
+			)
+			os.Exit(1)
+		}
+	}
+}
+
+func exceedsMaxSend(p boslib.PaymentPath) bool {
+	if len(flagMaxSend) < 1 {
+		return false
+	}
+
+	return amount.MustParse(p.SourceAmount) > amount.MustParse(flagMaxSend)
+}
+
+func printPaths() {
+	t := template.Must(template.New("").Parse(
+		"{{ .index }}: send {{ .sourceAmount }} {{ .sourceAsset }} through [{{ .path }}]{{ if .exceeds }} (exceeds -max-send){{ end }}\n",
+	))
+
+	fmt.Printf(
+		"found %d paths to pay %s %s:\n",
+		len(paths),
+		flagDestinationAmount,
+		boslib.AssetString(destinationAsset),
+	)
+	for i, p := range paths {
+		var through []string
+		for _, a := range p.Path {
+			through = append(through, boslib.AssetString(a))
+		}
+
+		t.Execute(os.Stdout, map[string]interface{}{
+			"index":        fmt.Sprintf("%3d", i),
+			"sourceAmount": fmt.Sprintf("%20s", p.SourceAmount),
+			"sourceAsset":  boslib.AssetString(p.SourceAsset),
+			"path":         strings.Join(through, " -> "),
+			"exceeds":      exceedsMaxSend(p),
+		})
+	}
+}
+
+func selectPath() (index int, err error) {
+	if flagAuto {
+		// the paths are sorted by the source amount
+		return 0, nil
+	}
+
+	if flagPath >= 0 {
+		return flagPath, nil
+	}
+
+	fmt.Printf("select path [0-%d]: ", len(paths)-1)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	return strconv.Atoi(strings.TrimSpace(line))
+}
+
+func main() {
+	printPaths()
+
+	index, err := selectPath()
+	if err != nil {
+		log.Errorf("invalid path selected: %v", err)
+		os.Exit(1)
+	}
+	if index < 0 || index >= len(paths) {
+		log.Errorf("invalid path selected, %d; must be 0 to %d", index, len(paths)-1)
+		os.Exit(1)
+	}
+
+	selected := paths[index]
+	// without -max-send, the source amount can be changed until the
+	// transaction is applied, so the slippage is allowed
+	maxSend := flagMaxSend
+	if len(maxSend) < 1 {
+		if maxSend, err = boslib.AddSlippage(selected.SourceAmount, int64(flagSlippage*100+0.5)); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	}
+	if exceedsMaxSend(selected) {
+		log.Errorf(
+			"path, %d needs %s %s; it exceeds -max-send, %s",
+			index,
+			selected.SourceAmount,
+			boslib.AssetString(selected.SourceAsset),
+			flagMaxSend,
+		)
+		os.Exit(1)
+	}
+
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+	}
+
+	if flagDryRun {
+		p := boslib.NewPreview(flagHorizon)
+		_, err := p.Build(
+			flagSecretSeed,
+			flagSecretSeed,
+			networkPassphrase,
+			flagFee,
+			append(timeBounds.Mutators(), boslib.PathPaymentOperation(receiverKP.Address(), selected, maxSend))...,
+		)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		// at most the maximum send amount is sent
+		p.Debit(secretSeedKP.Address(), selected.SourceAsset, maxSend)
+		p.Credit(receiverKP.Address(), destinationAsset, flagDestinationAmount)
+
+		if err := p.Print(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	resp, err := boslib.SendPathPayment(
+		flagHorizon,
+		flagSecretSeed,
+		receiverKP.Address(),
+		networkPassphrase,
+		selected,
+		maxSend,
+		0,
+		flagFee,
+		timeBounds.Mutators()...,
+	)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	log.Debugf("transaction posted in ledger: %v", resp.Ledger)
+
+	fmt.Printf(
+		"%s %s sent to %s through path, %d(%s %s at most)\n",
+		flagDestinationAmount,
+		boslib.AssetString(destinationAsset),
+		receiverKP.Address(),
+		index,
+		maxSend,
+		boslib.AssetString(selected.SourceAsset),
+	)
+
+	os.Exit(0)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log *logrus.Logger
+var flags *flag.FlagSet
+
+var flagHorizon string
+var flagNetworkPassphrase string
+var flagVerbose bool
+var flagSecretSeed string
+var flagCSVFileName string
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagMemoText string
+var flagMemoID string
+var flagMemoHash string
+var flagMemoReturn string
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+var paymentData [][]boslib.PaymentRow
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.InfoLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <secret seed> <csv>")
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.StringVar(&flagMemoText, "memo-text", "", "text memo for the rows without memo, up to 28 bytes")
+	flags.StringVar(&flagMemoID, "memo-id", "", "id memo for the rows without memo, unsigned 64 bit integer")
+	flags.StringVar(&flagMemoHash, "memo-hash", "", "hash memo for the rows without memo, hex encoded 32 bytes")
+	flags.StringVar(&flagMemoReturn, "memo-return", "", "return memo for the rows without memo, hex encoded 32 bytes")
+
+	flags.Parse(os.Args[1:])
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	if flags.NArg() < 2 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+
+	// memo
+	var memo boslib.Memo
+	{
+		var err error
+		if memo, err = boslib.MemoFromFlags(flagMemoText, flagMemoID, flagMemoHash, flagMemoReturn); err != nil {
+			usage(err)
+		}
+	}
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if flagNetworkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		var secretSeedKP keypair.KP
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+
+		if exists, err := boslib.CheckAddressExists(flagHorizon, secretSeedKP.Address()); err != nil {
+			usage(err)
+		} else if !exists {
+			usage(fmt.Errorf(
+				"invalid <secret seed>, '%s'; the account was not found in network",
+				flagSecretSeed,
+			))
+		}
+	}
+
+	// csv: <public address>,<amount>[,<asset>[,<memo>[,<memo type>]]]
+	flagCSVFileName = strings.TrimSpace(flags.Arg(1))
+	{
+		records, err := boslib.ReadCSV(flagCSVFileName, 2, "address", "amount", "asset", "memo", "memo_type")
+		if err != nil {
+			usage(fmt.Errorf("failed to read csv file, '%s': %v", flagCSVFileName, err))
+		}
+
+		var rows []boslib.PaymentRow
+		for _, r := range records {
+			row := boslib.PaymentRow{
+				Line:    r.Line,
+				Address: r.Address,
+				Amount:  r.Amount,
+				Memo:    memo,
+			}
+
+			if _, err = keypair.Parse(row.Address); err != nil {
+				usage(fmt.Errorf("line %d: invalid <public address>, '%s': %v", r.Line, row.Address, err))
+			}
+
+			if a, err := amount.Parse(row.Amount); err != nil {
+				usage(fmt.Errorf("line %d: invalid <amount>, '%s': %v", r.Line, row.Amount, err))
+			} else if a < 1 {
+				usage(fmt.Errorf("line %d: invalid <amount>, '%s'; must be positive", r.Line, row.Amount))
+			}
+
+			asset := "native"
+			if len(r.Asset) > 0 {
+				asset = r.Asset
+			}
+			if row.Asset, err = boslib.ParseAsset(asset); err != nil {
+				usage(fmt.Errorf("line %d: invalid <asset>: %v", r.Line, err))
+			}
+
+			// the memo of row overrides the memo flags
+			if len(r.Memo) > 0 || len(r.MemoType) > 0 {
+				if row.Memo, err = boslib.ParseMemo(r.MemoType, r.Memo); err != nil {
+					usage(fmt.Errorf("line %d: invalid <memo>: %v", r.Line, err))
+				}
+			}
+
+			rows = append(rows, row)
+		}
+
+		paymentData = boslib.BatchPayments(rows)
+	}
+}
+
+func main() {
+	t := template.Must(template.New("").Parse(
+		"({{ if .err }}X{{ else }}O{{ end }}) {{ .r.Line }}: {{ .r.Address }} : {{ .r.Amount }} {{ .asset }}{{ if .memo }} '{{ .memo }}'{{ end }}{{ if .hash }} {{ .hash }}{{ end }}\n",
+	))
+
+	if feeAuto {
+		var n int
+		for _, rows := range paymentData {
+			n += len(rows)
+		}
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, n))
+	}
+
+	if flagDryRun {
+		p := boslib.NewPreview(flagHorizon)
+		for _, rows := range paymentData {
+			muts := append(timeBounds.Mutators(), rows[0].Memo.Mutators()...)
+			for _, r := range rows {
+				muts = append(muts, r.Operation())
+			}
+
+			tx, err := p.Build(flagSecretSeed, flagSecretSeed, flagNetworkPassphrase, flagFee, muts...)
+			if err != nil {
+				log.Error(err)
+				os.Exit(1)
+			}
+			for _, r := range rows {
+				p.Debit(tx.Source, r.Asset, r.Amount)
+				p.Credit(r.Address, r.Asset, r.Amount)
+			}
+		}
+
+		if err := p.Print(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	var succeeded, failed int
+	for _, rows := range paymentData {
+		resp, err := boslib.SendPayments(
+			flagHorizon,
+			flagSecretSeed,
+			flagNetworkPassphrase,
+			0,
+			flagFee,
+			rows,
+			timeBounds.Mutators()...,
+		)
+
+		for _, r := range rows {
+			var memo string
+			if !r.Memo.IsEmpty() {
+				memo = r.Memo.String()
+			}
+
+			t.Execute(os.Stdout, map[string]interface{}{
+				"r":     r,
+				"memo":  memo,
+				"asset": boslib.AssetString(r.Asset),
+				"err":   err != nil,
+				"hash":  resp.Hash,
+			})
+		}
+		if err != nil {
+			failed += len(rows)
+			log.Error(err)
+		} else {
+			succeeded += len(rows)
+		}
+	}
+
+	log.Infof("%d payments succeeded and %d payments failed", succeeded, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -18,7 +18,10 @@ import (
 	"github.com/spikeekips/stellar-utils/boslib"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
 )
 
 type Balance struct {
@@ -35,8 +38,18 @@ var flagSecretSeed string
 var flagReceiverAddress string
 var flagAmount float64
 var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagMemoText string
+var flagMemoID string
+var flagMemoHash string
+var flagMemoReturn string
+var flagYes bool
+var flagRetypeAmount float64
 
 var networkPassphrase string
+var memo boslib.Memo
 var secretSeedKP keypair.KP
 var receiverKP keypair.KP
 var sender_balances_before []Balance
@@ -50,6 +63,9 @@ func usage(err error) {
 	os.Exit(1)
 }
 
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
 func checkAddressExists(horizonUrl, address string) (exists bool, err error) {
 	exists = false
 
@@ -139,7 +155,15 @@ func init() {
 
 	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
 	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
-	flags.Uint64Var(&flagFee, "fee", boslib.DefaultFee, "transaction fee")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.StringVar(&flagMemoText, "memo-text", "", "text memo, up to 28 bytes")
+	flags.StringVar(&flagMemoID, "memo-id", "", "id memo, unsigned 64 bit integer")
+	flags.StringVar(&flagMemoHash, "memo-hash", "", "hash memo, hex encoded 32 bytes")
+	flags.StringVar(&flagMemoReturn, "memo-return", "", "return memo, hex encoded 32 bytes")
+	flags.BoolVar(&flagYes, "yes", false, "submit without confirmation")
+	flags.Float64Var(&flagRetypeAmount, "retype-amount", 0, "over the amount, the amount must be typed again to confirm; 0 is disabled")
 
 	flags.Parse(os.Args[1:])
 
@@ -162,8 +186,8 @@ func init() {
 		flagReceiverAddress, utf8.RuneCountInString(flagReceiverAddress), flagReceiverAddress)
 	log.Debugf("given               flagAmount: %T:%4d: %v",
 		flagAmount, utf8.RuneCountInString(fmt.Sprintf("%v", flagAmount)), flagAmount)
-	log.Debugf("given                  flagFee: %T:%4d: %v",
-		flagFee, utf8.RuneCountInString(fmt.Sprintf("%v", flagFee)), flagFee)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
 
 	// amount
 	{
@@ -174,6 +198,18 @@ func init() {
 		}
 	}
 
+	if flagRetypeAmount < 0 {
+		usage(fmt.Errorf("invalid -retype-amount, %v; must be positive", flagRetypeAmount))
+	}
+
+	// memo
+	{
+		var err error
+		if memo, err = boslib.MemoFromFlags(flagMemoText, flagMemoID, flagMemoHash, flagMemoReturn); err != nil {
+			usage(err)
+		}
+	}
+
 	// horizon
 	{
 		var err error
@@ -215,6 +251,22 @@ func init() {
 		}
 	}
 
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
 	// secret seed
 	{
 		var err error
@@ -267,11 +319,34 @@ func init() {
 		}
 	}
 
+	// spendable; the sender must afford the amount and fee over it's minimum
+	// balance by the base reserve of the latest ledger.
+	{
+		ledger, err := boslib.LoadLatestLedger(flagHorizon)
+		if err != nil {
+			usage(err)
+		}
+		if err = ledger.CheckFee(flagFee); err != nil {
+			usage(fmt.Errorf("invalid -fee: %v", err))
+		}
+
+		account, err := boslib.LoadAccount(flagHorizon, secretSeedKP.Address())
+		if err != nil {
+			usage(err)
+		}
+
+		a, _ := amount.Parse(fmt.Sprintf("%0.7f", flagAmount))
+		if err = ledger.CheckSpendable(account, a+xdr.Int64(flagFee)); err != nil {
+			usage(err)
+		}
+	}
+
 	log.Debugf("parsed              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
 	log.Debugf("parsed    networkPassphrase: %T:%4d: %v",
 		networkPassphrase, utf8.RuneCountInString(networkPassphrase), networkPassphrase)
 	log.Debugf("parsed           flagSecretSeed: %T:%4d: %v",
 		flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("parsed                     memo: %T:%4d: %v", memo, utf8.RuneCountInString(memo.String()), memo)
 	log.Debugf("parsed               flagAmount: %T:%4d: %v",
 		flagAmount, utf8.RuneCountInString(fmt.Sprintf("%v", flagAmount)), flagAmount)
 	log.Debugf("parsed      flagRecieverAddress: %T:%4d: %v",
@@ -280,7 +355,91 @@ func init() {
 	log.Debugf("              receiver balances: %20.7f", receiver_balances_before[0].Amount)
 }
 
+// confirm shows the payment and asks to type 'yes'; if the amount is over
+// -retype-amount, the amount must be typed again instead.
+func confirm() (bool, error) {
+	var memoString string
+	if !memo.IsEmpty() {
+		memoString = memo.String()
+	}
+
+	if len(memoString) < 1 {
+		memoString = "-"
+	}
+
+	fmt.Fprintf(os.Stderr, "    network: %s\n", boslib.NetworkName(networkPassphrase))
+	fmt.Fprintf(os.Stderr, "     source: %s\n", secretSeedKP.Address())
+	fmt.Fprintf(os.Stderr, "destination: %s\n", receiverKP.Address())
+	fmt.Fprintf(os.Stderr, "     amount: %0.7f %s\n", flagAmount, boslib.AssetString(b.NativeAsset()))
+	fmt.Fprintf(os.Stderr, "       memo: %s\n", memoString)
+	fmt.Fprintf(os.Stderr, "        fee: %s\n", boslib.FeeSummary(flagFee, 1))
+
+	if flagRetypeAmount > 0 && flagAmount > flagRetypeAmount {
+		answer, err := boslib.Ask(os.Stdin, os.Stderr, "type the amount again to submit: ")
+		if err != nil {
+			return false, err
+		}
+
+		a, err := amount.Parse(answer)
+		if err != nil {
+			return false, nil
+		}
+		expected, _ := amount.Parse(fmt.Sprintf("%0.7f", flagAmount))
+
+		return a == expected, nil
+	}
+
+	answer, err := boslib.Ask(os.Stdin, os.Stderr, "type 'yes' to submit: ")
+	if err != nil {
+		return false, err
+	}
+
+	return answer == "yes", nil
+}
+
 func main() {
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, 1))
+	}
+
+	if flagDryRun {
+		amount := fmt.Sprintf("%0.7f", flagAmount)
+
+		p := boslib.NewPreview(flagHorizon)
+		_, err := p.Build(
+			flagSecretSeed,
+			flagSecretSeed,
+			networkPassphrase,
+			flagFee,
+			append(
+				append(memo.Mutators(), timeBounds.Mutators()...),
+				boslib.PaymentOperation(receiverKP.Address(), amount),
+			)...,
+		)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		p.Debit(secretSeedKP.Address(), b.NativeAsset(), amount)
+		p.Credit(receiverKP.Address(), b.NativeAsset(), amount)
+
+		if err := p.Print(os.Stdout); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if !flagYes {
+		if confirmed, err := confirm(); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		} else if !confirmed {
+			log.Error("payment was not confirmed; nothing was submitted")
+			os.Exit(1)
+		}
+	}
+
 	resp, err := boslib.SendPayment(
 		flagHorizon,
 		flagSecretSeed,
@@ -289,6 +448,7 @@ func main() {
 		networkPassphrase,
 		0,
 		flagFee,
+		append(memo.Mutators(), timeBounds.Mutators()...)...,
 	)
 	if err != nil {
 		log.Error(err)
@@ -312,8 +472,13 @@ func main() {
 		}
 	}
 
+	var memoString string
+	if !memo.IsEmpty() {
+		memoString = memo.String()
+	}
+
 	t := template.Must(template.New("").Parse(strings.TrimSpace(`
-{{ .amount }} sent from {{ .from_address }} to {{ .to_address }} successfully
+{{ .amount }} sent from {{ .from_address }} to {{ .to_address }} successfully{{ if .memo }} with memo, {{ .memo }}{{ end }}
 
   sender: {{ .senderDiff }}: {{ .senderBefore }} -> {{ .senderAfter }}
 receiver: {{ .receiverDiff }}: {{ .receiverBefore }} -> {{ .receiverAfter }}
@@ -322,6 +487,7 @@ receiver: {{ .receiverDiff }}: {{ .receiverBefore }} -> {{ .receiverAfter }}
 		"to_address":     receiverKP.Address(),
 		"from_address":   secretSeedKP.Address(),
 		"amount":         fmt.Sprintf("%0.7f", flagAmount),
+		"memo":           memoString,
 		"senderBefore":   fmt.Sprintf("%20.7f", sender_balances_before[0].Amount),
 		"senderAfter":    fmt.Sprintf("%20.7f", sender_balances_after[0].Amount),
 		"senderDiff":     fmt.Sprintf("%20.7f", sender_balances_before[0].Amount-sender_balances_after[0].Amount),
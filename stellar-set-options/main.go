@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+)
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, strings.TrimSpace(v))
+	return nil
+}
+
+var log *logrus.Logger
+
+var flags *flag.FlagSet
+var flagVerbose bool
+var flagHorizon string
+var flagSecretSeed string
+var flagFee uint64
+var feeFlags *boslib.FeeFlags
+var flagDryRun bool
+var flagTimeBounds *boslib.TimeBoundsFlags
+var flagForce bool
+var flagAddSigners stringsFlag
+var flagRemoveSigners stringsFlag
+var flagMasterWeight int
+var flagLowThreshold int
+var flagMedThreshold int
+var flagHighThreshold int
+var flagSetFlags string
+var flagClearFlags string
+var flagHomeDomain string
+var flagInflationDest string
+
+var networkPassphrase string
+var secretSeedKP keypair.KP
+var change boslib.OptionsChange
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+var timeBounds boslib.TimeBounds
+var feeAuto bool
+
+// parseWeight parses weight and threshold; -1 means not changed.
+func parseWeight(name string, v int) (*uint32, error) {
+	if v < 0 {
+		return nil, nil
+	}
+	if v > 255 {
+		return nil, fmt.Errorf("invalid -%s, %d; must be 0 to 255", name, v)
+	}
+
+	w := uint32(v)
+	return &w, nil
+}
+
+var setFlags = map[string]func() b.SetFlag{
+	"auth_required":  b.SetAuthRequired,
+	"auth_revocable": b.SetAuthRevocable,
+	"auth_immutable": b.SetAuthImmutable,
+}
+
+var clearFlags = map[string]func() b.ClearFlag{
+	"auth_required":  b.ClearAuthRequired,
+	"auth_revocable": b.ClearAuthRevocable,
+	"auth_immutable": b.ClearAuthImmutable,
+}
+
+// parseAuthFlags parses the flag names separated by comma.
+func parseAuthFlags(s string) (names []string, err error) {
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if len(f) < 1 {
+			continue
+		}
+
+		if _, found := setFlags[f]; !found {
+			err = fmt.Errorf("unknown flag, '%s'; must be 'auth_required', 'auth_revocable' or 'auth_immutable'", f)
+			return
+		}
+		names = append(names, f)
+	}
+
+	return
+}
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.ErrorLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <secret seed>")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	feeFlags = boslib.SetFeeFlags(flags)
+	flags.BoolVar(&flagDryRun, "dry-run", false, "build and sign the transaction, but do not submit it")
+	flagTimeBounds = boslib.SetTimeBoundsFlags(flags)
+	flags.BoolVar(&flagForce, "force", false, "change options even if the account will be locked out")
+	flags.Var(&flagAddSigners, "add-signer", "add or reweight signer, '<public address>:<weight>'; can be given multiple times")
+	flags.Var(&flagRemoveSigners, "remove-signer", "remove signer, '<public address>'; can be given multiple times")
+	flags.IntVar(&flagMasterWeight, "master-weight", -1, "master weight, 0 to 255")
+	flags.IntVar(&flagLowThreshold, "low-threshold", -1, "low threshold, 0 to 255")
+	flags.IntVar(&flagMedThreshold, "med-threshold", -1, "medium threshold, 0 to 255")
+	flags.IntVar(&flagHighThreshold, "high-threshold", -1, "high threshold, 0 to 255")
+	flags.StringVar(&flagSetFlags, "set-flags", "", "set flags, 'auth_required', 'auth_revocable', 'auth_immutable' separated by comma")
+	flags.StringVar(&flagClearFlags, "clear-flags", "", "clear flags, 'auth_required', 'auth_revocable', 'auth_immutable' separated by comma")
+	flags.StringVar(&flagHomeDomain, "home-domain", "", "home domain")
+	flags.StringVar(&flagInflationDest, "inflation-dest", "", "public address of inflation destination")
+
+	flags.Parse(os.Args[1:])
+
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+
+	if flags.NArg() < 1 {
+		usage(fmt.Errorf("insufficient arguments"))
+	}
+
+	flagSecretSeed = strings.TrimSpace(flags.Arg(0))
+
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given           flagSecretSeed: %T:%4d: %v", flagSecretSeed, utf8.RuneCountInString(flagSecretSeed), flagSecretSeed)
+	log.Debugf("given             feeFlags.Fee: %T:%4d: %v",
+		feeFlags.Fee, utf8.RuneCountInString(feeFlags.Fee), feeFlags.Fee)
+
+	// options
+	{
+		var err error
+		if change.MasterWeight, err = parseWeight("master-weight", flagMasterWeight); err != nil {
+			usage(err)
+		}
+		if change.LowThreshold, err = parseWeight("low-threshold", flagLowThreshold); err != nil {
+			usage(err)
+		}
+		if change.MedThreshold, err = parseWeight("med-threshold", flagMedThreshold); err != nil {
+			usage(err)
+		}
+		if change.HighThreshold, err = parseWeight("high-threshold", flagHighThreshold); err != nil {
+			usage(err)
+		}
+
+		for _, s := range flagAddSigners {
+			a := strings.SplitN(s, ":", 2)
+			if len(a) != 2 {
+				usage(fmt.Errorf("invalid -add-signer, '%s'; must be '<public address>:<weight>'", s))
+			}
+
+			var kp keypair.KP
+			if kp, err = keypair.Parse(strings.TrimSpace(a[0])); err != nil {
+				usage(fmt.Errorf("invalid -add-signer, '%s': %v", s, err))
+			}
+
+			var weight int
+			if weight, err = strconv.Atoi(strings.TrimSpace(a[1])); err != nil || weight < 1 || weight > 255 {
+				usage(fmt.Errorf("invalid -add-signer, '%s'; weight must be 1 to 255", s))
+			}
+			change.Signers = append(change.Signers, b.Signer{Address: kp.Address(), Weight: uint32(weight)})
+		}
+
+		for _, s := range flagRemoveSigners {
+			var kp keypair.KP
+			if kp, err = keypair.Parse(s); err != nil {
+				usage(fmt.Errorf("invalid -remove-signer, '%s': %v", s, err))
+			}
+			change.Signers = append(change.Signers, b.Signer{Address: kp.Address(), Weight: 0})
+		}
+
+		var names []string
+		if names, err = parseAuthFlags(flagSetFlags); err != nil {
+			usage(fmt.Errorf("invalid -set-flags: %v", err))
+		}
+		for _, f := range names {
+			change.SetFlags = append(change.SetFlags, setFlags[f]())
+		}
+		if names, err = parseAuthFlags(flagClearFlags); err != nil {
+			usage(fmt.Errorf("invalid -clear-flags: %v", err))
+		}
+		for _, f := range names {
+			change.ClearFlags = append(change.ClearFlags, clearFlags[f]())
+		}
+
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "home-domain":
+				homeDomain := strings.TrimSpace(flagHomeDomain)
+				if len(homeDomain) > 32 {
+					usage(fmt.Errorf("invalid -home-domain, '%s'; must be shorter than 33 characters", homeDomain))
+				}
+				change.HomeDomain = &homeDomain
+			case "inflation-dest":
+				var kp keypair.KP
+				if kp, err = keypair.Parse(strings.TrimSpace(flagInflationDest)); err != nil {
+					usage(fmt.Errorf("invalid -inflation-dest, '%s': %v", flagInflationDest, err))
+				}
+				inflationDest := kp.Address()
+				change.InflationDest = &inflationDest
+			}
+		})
+
+		if change.IsEmpty() {
+			usage(fmt.Errorf("nothing to change"))
+		}
+	}
+
+	// horizon
+	{
+		var err error
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if networkPassphrase, err = boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// fee
+	{
+		var err error
+		if flagFee, feeAuto, err = feeFlags.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// time bounds
+	{
+		var err error
+		if timeBounds, err = flagTimeBounds.Resolve(flagHorizon); err != nil {
+			usage(err)
+		}
+	}
+
+	// secret seed
+	{
+		var err error
+		if secretSeedKP, err = keypair.Parse(flagSecretSeed); err != nil {
+			usage(fmt.Errorf("invalid <secret seed>: %v", err))
+		} else if string([]rune(flagSecretSeed)[0]) != "S" {
+			usage(fmt.Errorf("not <secret seed>, this is public address"))
+		}
+	}
+
+	// lock-out protection
+	{
+		account, err := boslib.LoadAccount(flagHorizon, secretSeedKP.Address())
+		if err != nil {
+			usage(err)
+		}
+
+		if err = boslib.CheckLockout(account, change); err != nil {
+			if !flagForce {
+				usage(fmt.Errorf("%v; to change anyway, use -force", err))
+			}
+			log.Debugf("ignore lock-out protection by -force: %v", err)
+		}
+	}
+}
+
+func main() {
+	if feeAuto {
+		fmt.Fprintln(os.Stderr, "fee:", boslib.FeeSummary(flagFee, len(change.Operations())))
+	}
+
+	if flagDryRun {
+		if err := boslib.PreviewOperations(os.Stdout, flagHorizon, flagSecretSeed, networkPassphrase, flagFee, append(timeBounds.Mutators(), change.Operations()...)...); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	resp, err := boslib.SubmitOperations(
+		flagHorizon,
+		flagSecretSeed,
+		networkPassphrase,
+		0,
+		flagFee,
+		append(timeBounds.Mutators(), change.Operations()...)...,
+	)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("options of '%s' changed in ledger, %d: %s\n", secretSeedKP.Address(), resp.Ledger, resp.Hash)
+
+	os.Exit(0)
+}
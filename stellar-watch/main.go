@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spikeekips/stellar-utils/boslib"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/keypair"
+)
+
+var log *logrus.Logger
+var flags *flag.FlagSet
+
+var flagHorizon string
+var flagVerbose bool
+var flagType string
+var flagCursor string
+var flagCursorFile string
+var flagRetry time.Duration
+var flagJSON bool
+
+var address keypair.KP
+var elem []string
+
+func usage(err error) {
+	if err != nil {
+		log.Error(err)
+	}
+	flags.Usage()
+	os.Exit(1)
+}
+
+func init() {
+	log = logrus.New()
+	log.Level = logrus.InfoLevel
+	boslib.SetLevel(log.Level)
+
+	flags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println(filepath.Base(os.Args[0]), "[options] <public address>")
+		fmt.Println(filepath.Base(os.Args[0]), "[options] -type ledgers")
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&flagVerbose, "verbose", false, "verbose")
+	flags.StringVar(&flagHorizon, "horizon", "", "horizon server address")
+	flags.StringVar(&flagType, "type", "payments", "'payments' or 'transactions' of account, or 'ledgers'")
+	flags.StringVar(&flagCursor, "cursor", "now", "start after the cursor; 'now' is from the next event")
+	flags.StringVar(&flagCursorFile, "cursor-file", "", "the last cursor is kept in the file and it is resumed from the cursor in the file")
+	flags.DurationVar(&flagRetry, "retry-interval", 5*time.Second, "interval to connect again after disconnected")
+	flags.BoolVar(&flagJSON, "json", false, "print the events in json, one event per line")
+
+	flags.Parse(os.Args[1:])
+	if flagVerbose {
+		log.Level = logrus.DebugLevel
+		boslib.SetLevel(log.Level)
+	}
+
+	log.Debugf("arguments: %v", os.Args)
+	log.Debugf("given              flagHorizon: %T:%4d: %v", flagHorizon, utf8.RuneCountInString(flagHorizon), flagHorizon)
+	log.Debugf("given                 flagType: %T:%4d: %v", flagType, utf8.RuneCountInString(flagType), flagType)
+	log.Debugf("given           flagCursorFile: %T:%4d: %v", flagCursorFile, utf8.RuneCountInString(flagCursorFile), flagCursorFile)
+
+	switch flagType {
+	case "payments", "transactions":
+		if flags.NArg() < 1 {
+			usage(errors.New("<public address> is missing"))
+		}
+
+		var err error
+		if address, err = keypair.Parse(strings.TrimSpace(flags.Arg(0))); err != nil {
+			usage(fmt.Errorf("invalid <public address>: %v", err))
+		}
+		elem = []string{"accounts", address.Address(), flagType}
+	case "ledgers":
+		if flags.NArg() > 0 {
+			usage(errors.New("<public address> can not be given with '-type ledgers'"))
+		}
+		elem = []string{"ledgers"}
+	default:
+		usage(fmt.Errorf("invalid -type, '%s'; must be 'payments', 'transactions' or 'ledgers'", flagType))
+	}
+
+	if flagRetry <= 0 {
+		usage(fmt.Errorf("invalid -retry-interval, %v; must be positive", flagRetry))
+	}
+
+	// cursor
+	{
+		flagCursor = strings.TrimSpace(flagCursor)
+
+		// the explicitly given -cursor wins over the cursor in -cursor-file
+		var cursorGiven bool
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "cursor" {
+				cursorGiven = true
+			}
+		})
+
+		if len(flagCursorFile) > 0 {
+			b, err := ioutil.ReadFile(flagCursorFile)
+			if err != nil && !os.IsNotExist(err) {
+				usage(fmt.Errorf("failed to read -cursor-file, '%s': %v", flagCursorFile, err))
+			} else if c := strings.TrimSpace(string(b)); len(c) > 0 && cursorGiven {
+				log.Infof("-cursor, '%s' is given, so the cursor, '%s' in '%s' is ignored", flagCursor, c, flagCursorFile)
+			} else if len(c) > 0 {
+				log.Infof("resume from cursor, '%s' in '%s'", c, flagCursorFile)
+				flagCursor = c
+			}
+		}
+	}
+
+	// horizon
+	{
+		flagHorizon = strings.TrimSpace(flagHorizon)
+		if _, err := boslib.CheckHorizon(flagHorizon); err != nil {
+			usage(err)
+		}
+
+		if address != nil {
+			if exists, err := boslib.CheckAddressExists(flagHorizon, address.Address()); err != nil {
+				usage(err)
+			} else if !exists {
+				usage(fmt.Errorf("account, '%s' does not exist", address.Address()))
+			}
+		}
+	}
+}
+
+// writeCursor keeps the cursor in -cursor-file; it is written to the
+// temporary file first, so the cursor file is not broken by interruption.
+func writeCursor(cursor string) error {
+	tmp := flagCursorFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(cursor+"\n"), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, flagCursorFile)
+}
+
+// printEvent prints the record of event in one line.
+func printEvent(data []byte) error {
+	if flagJSON {
+		var b bytes.Buffer
+		if err := json.Compact(&b, data); err != nil {
+			return fmt.Errorf("invalid event received: %v", err)
+		}
+		fmt.Println(b.String())
+		return nil
+	}
+
+	if flagType == "ledgers" {
+		var l boslib.Ledger
+		if err := json.Unmarshal(data, &l); err != nil {
+			return fmt.Errorf("invalid ledger received: %v", err)
+		}
+		fmt.Printf(
+			"%s ledger %d: %d transactions, %d operations\n",
+			l.ClosedAt.Format(time.RFC3339),
+			l.Sequence,
+			l.TransactionCount,
+			l.OperationCount,
+		)
+		return nil
+	}
+
+	var r boslib.HistoryRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("invalid %s received: %v", flagType, err)
+	}
+	fmt.Println(r.Summary())
+
+	return nil
+}
+
+func main() {
+	err := boslib.Watch(
+		flagHorizon,
+		flagCursor,
+		flagRetry,
+		func(cursor string, data []byte) error {
+			if err := printEvent(data); err != nil {
+				log.Error(err)
+			}
+
+			if len(flagCursorFile) > 0 && len(cursor) > 0 {
+				if err := writeCursor(cursor); err != nil {
+					return fmt.Errorf("failed to write -cursor-file, '%s': %v", flagCursorFile, err)
+				}
+			}
+
+			return nil
+		},
+		elem...,
+	)
+
+	log.Error(err)
+	os.Exit(1)
+}